@@ -0,0 +1,256 @@
+// activity.go implements the server activity monitor, a mode listing the
+// sessions connected to the server with user, state, duration and query,
+// refreshed on an interval via tea.Tick. It can show sessions as a lock
+// blocking tree and cancel or kill the selected session after confirmation.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const activityRefreshInterval = 2 * time.Second
+
+// activityTickMsg triggers a refresh. seq guards against ticks scheduled
+// before the monitor was left, paused or refreshed manually.
+type activityTickMsg struct {
+	seq int
+}
+
+func (a *App) scheduleActivityTick() tea.Cmd {
+	a.activitySeq++
+	seq := a.activitySeq
+	return tea.Tick(activityRefreshInterval, func(time.Time) tea.Msg {
+		return activityTickMsg{seq: seq}
+	})
+}
+
+func (a *App) startActivity() tea.Cmd {
+	a.activityCursor = 0
+	a.activityPaused = false
+	a.activityConfirm = ""
+	a.activityStatus = ""
+	a.activityCmdErr = nil
+	a.refreshActivity()
+	a.mode = modeActivity
+	return a.scheduleActivityTick()
+}
+
+func (a *App) stopActivity() {
+	a.activitySeq++
+	a.activitySessions = nil
+	a.mode = modeConnected
+}
+
+func (a *App) refreshActivity() {
+	a.activitySessions, a.activityErr = db.ListSessions(a.db, a.dbType)
+	a.activityLastRun = time.Now()
+}
+
+func (a *App) handleActivityTick(msg activityTickMsg) (tea.Model, tea.Cmd) {
+	if a.mode != modeActivity || a.activityPaused || msg.seq != a.activitySeq {
+		return a, nil
+	}
+	// Keep the list still while a cancel/kill is being confirmed
+	if a.activityConfirm == "" {
+		a.refreshActivity()
+	}
+	return a, a.scheduleActivityTick()
+}
+
+// activityRows returns the visible sessions with their tree depth: the lock
+// tree, or every session with idle ones hidden unless shown
+func (a *App) activityRows() []db.LockTreeEntry {
+	if a.activityLockTree {
+		return db.LockTree(a.activitySessions)
+	}
+	var rows []db.LockTreeEntry
+	for _, s := range a.activitySessions {
+		if a.activityShowIdle || !s.IsIdle() {
+			rows = append(rows, db.LockTreeEntry{Session: s})
+		}
+	}
+	return rows
+}
+
+func (a *App) updateActivity(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := a.activityRows()
+	a.activityCursor = min(a.activityCursor, max(0, len(rows)-1))
+
+	if a.activityConfirm != "" {
+		switch msg.String() {
+		case "y", "Y":
+			var err error
+			if a.activityConfirm == "kill" {
+				err = db.KillSession(a.db, a.activityTarget.ID, a.dbType)
+			} else {
+				err = db.CancelSession(a.db, a.activityTarget.ID, a.dbType)
+			}
+			logErr := a.audit(fmt.Sprintf("-- %s session %s (%s)", a.activityConfirm, a.activityTarget.ID, a.activityTarget.User), -1, err)
+			a.activityCmdErr = err
+			if err == nil {
+				a.activityStatus = fmt.Sprintf("Sent %s to session %s", a.activityConfirm, a.activityTarget.ID)
+				a.refreshActivity()
+			}
+			if logErr != nil {
+				a.activityCmdErr = logErr
+			}
+			a.activityConfirm = ""
+		case "n", "N", "esc":
+			a.activityConfirm = ""
+		}
+		return a, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		a.stopActivity()
+	case "j", "down":
+		a.activityCursor = moveCursor(a.activityCursor, 1, len(rows))
+	case "k", "up":
+		a.activityCursor = moveCursor(a.activityCursor, -1, len(rows))
+	case "i":
+		a.activityShowIdle = !a.activityShowIdle
+		a.activityCursor = 0
+	case "t":
+		a.activityLockTree = !a.activityLockTree
+		a.activityCursor = 0
+	case "r":
+		a.refreshActivity()
+		if !a.activityPaused {
+			return a, a.scheduleActivityTick()
+		}
+	case " ":
+		a.activityPaused = !a.activityPaused
+		if !a.activityPaused {
+			a.refreshActivity()
+			return a, a.scheduleActivityTick()
+		}
+	case "c", "K":
+		if a.readOnly() {
+			a.activityCmdErr = fmt.Errorf("%w: cannot cancel or kill sessions", errReadOnly)
+			return a, nil
+		}
+		if len(rows) > 0 {
+			a.activityTarget = rows[a.activityCursor].Session
+			a.activityConfirm = "cancel"
+			if msg.String() == "K" {
+				a.activityConfirm = "kill"
+			}
+			a.activityStatus = ""
+			a.activityCmdErr = nil
+		}
+	}
+	return a, nil
+}
+
+// formatDuration renders a session duration compactly, e.g. 850ms, 12s, 5m03s
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// oneLine collapses whitespace so a query fits on a single list row
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func (a *App) viewActivity() string {
+	var b strings.Builder
+	title := "Server activity"
+	if a.activityLockTree {
+		title = "Lock tree"
+	}
+	b.WriteString(selectedStyle.Render(title))
+	b.WriteString(dimStyle.Render("  refreshed " + a.activityLastRun.Format("15:04:05")))
+	if a.activityPaused {
+		b.WriteString(editingStyle.Render("  PAUSED"))
+	}
+	b.WriteString("\n\n")
+
+	if a.activityErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.activityErr.Error()))
+		b.WriteString("\n\n")
+	}
+
+	rows := a.activityRows()
+	if len(rows) == 0 {
+		if a.activityLockTree {
+			b.WriteString(dimStyle.Render("No sessions are waiting on locks"))
+		} else {
+			b.WriteString(dimStyle.Render("No active sessions (i: show idle)"))
+		}
+	} else {
+		a.activityCursor = min(a.activityCursor, len(rows)-1)
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %-7s %-12s %-14s %8s  %s", "ID", "User", "State", "Time", "Query")))
+		b.WriteString("\n")
+
+		width := max(20, a.viewport.Width)
+		var lines []string
+		for i, row := range rows {
+			s := row.Session
+			id := s.ID
+			if row.Depth > 0 {
+				id = strings.Repeat("  ", row.Depth-1) + "└ " + id
+			}
+			line := fmt.Sprintf("%-7s %-12.12s %-14.14s %8s  %s", id, s.User, s.State, formatDuration(s.Duration), oneLine(s.Query))
+			line = truncate(line, width-3)
+			switch {
+			case i == a.activityCursor:
+				lines = append(lines, "> "+selectedStyle.Render(line))
+			case len(s.BlockedBy) > 0:
+				lines = append(lines, "  "+errorStyle.Render(line))
+			default:
+				lines = append(lines, "  "+line)
+			}
+		}
+
+		height := max(3, a.viewport.Height-8)
+		offset := max(0, min(a.activityCursor-height/2, len(lines)-height))
+		end := min(len(lines), offset+height)
+		b.WriteString(strings.Join(lines[offset:end], "\n"))
+		b.WriteString("\n\n")
+
+		s := rows[a.activityCursor].Session
+		b.WriteString(valueStyle.Render(fmt.Sprintf("%s@%s  db: %s", s.User, s.Host, s.Database)))
+		b.WriteString("\n")
+		if s.Wait != "" {
+			b.WriteString(dimStyle.Render("Waiting on: "+s.Wait) + "\n")
+		}
+		if len(s.BlockedBy) > 0 {
+			b.WriteString(errorStyle.Render("Blocked by: "+strings.Join(s.BlockedBy, ", ")) + "\n")
+		}
+		if s.Query != "" {
+			b.WriteString(dimStyle.Render(oneLine(s.Query)) + "\n")
+		}
+	}
+
+	if a.activityConfirm != "" {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(fmt.Sprintf("%s session %s (%s)? y/n",
+			strings.ToUpper(a.activityConfirm[:1])+a.activityConfirm[1:], a.activityTarget.ID, a.activityTarget.User)))
+		return a.renderFrame(b.String(), "y: confirm • n/esc: cancel")
+	}
+	if a.activityCmdErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render("Error: " + a.activityCmdErr.Error()))
+	}
+	if a.activityStatus != "" {
+		b.WriteString("\n")
+		b.WriteString(selectedStyle.Render(a.activityStatus))
+	}
+
+	controls := "j/k: navigate • t: lock tree • i: idle • space: pause • r: refresh • c: cancel query • K: kill session • esc: back • q: quit"
+	return a.renderFrame(b.String(), controls)
+}
@@ -74,6 +74,10 @@ func (a *App) handleEditorFinished(msg editorFinishedMsg) (tea.Model, tea.Cmd) {
 	}
 
 	query := strings.TrimRight(string(content), "\n\r")
+	if query != a.queryInput.Value() {
+		a.queryArgs = nil
+		a.stopWatch()
+	}
 	a.queryInput.SetValue(query)
 	a.queryInput.Focus()
 
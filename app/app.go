@@ -22,8 +22,11 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case clearCopyMsg:
 		a.copySuccess = false
 		return a, nil
+	case clearSnapshotMsg:
+		a.snapshotTaken = false
+		return a, nil
 	case spinner.TickMsg:
-		if a.inputTesting {
+		if a.inputTesting || a.comparing || a.schemaDiffing {
 			var cmd tea.Cmd
 			a.inputSpinner, cmd = a.inputSpinner.Update(msg)
 			return a, cmd
@@ -33,13 +36,24 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.handleConnectionTestResult(msg)
 	case editorFinishedMsg:
 		return a.handleEditorFinished(msg)
+	case watchTickMsg:
+		return a.handleWatchTick(msg)
+	case tableCompareMsg:
+		return a.handleTableCompareResult(msg)
+	case schemaDiffMsg:
+		return a.handleSchemaDiffResult(msg)
+	case activityTickMsg:
+		return a.handleActivityTick(msg)
 	case tea.KeyMsg:
-		if msg.String() == "q" {
-			if a.db != nil {
-				a.db.Close()
-			}
+		// A typed confirmation takes every key, so a table name with a q
+		// in it does not quit
+		if msg.String() == "q" && !a.impactTyping {
+			a.closeAllSessions()
 			return a, tea.Quit
 		}
+		if a.showingSessions {
+			return a.updateSessionSwitcher(msg)
+		}
 		switch a.mode {
 		case modeList:
 			return a.updateList(msg)
@@ -51,6 +65,8 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.updateQuery(msg)
 		case modeTableList:
 			return a.updateTableList(msg)
+		case modeActivity:
+			return a.updateActivity(msg)
 		}
 	}
 	return a, nil
@@ -61,6 +77,13 @@ func (a *App) View() string {
 		return "Loading..."
 	}
 
+	// The status line and tab bar depend on the mode
+	a.updateViewportSize()
+
+	if a.showingSessions {
+		return a.viewSessionSwitcher()
+	}
+
 	switch a.mode {
 	case modeList:
 		return a.viewList()
@@ -72,6 +95,8 @@ func (a *App) View() string {
 		return a.viewQuery()
 	case modeTableList:
 		return a.viewTableList()
+	case modeActivity:
+		return a.viewActivity()
 	default:
 		return a.viewList()
 	}
@@ -89,14 +114,24 @@ func (a *App) renderFrame(content, controls string) string {
 	}
 
 	box := boxStyle.Width(boxWidth - 4)
+	if a.mode != modeList && a.mode != modeInput && a.currentConn().Production {
+		box = box.BorderForeground(ColorError)
+	}
 	boxedContent := box.Render(content)
 
 	logoRendered = lipgloss.PlaceHorizontal(a.width, lipgloss.Center, logoRendered)
 	controlsRendered := lipgloss.PlaceHorizontal(a.width, lipgloss.Center, dimStyle.Render(controls))
 	boxedContent = lipgloss.PlaceHorizontal(a.width, lipgloss.Center, boxedContent)
 
-	// Build frame with logo and box
-	frame := logoRendered + "\n" + boxedContent
+	// Build frame with logo, connection status, tab bar (query mode only) and box
+	frame := logoRendered + "\n"
+	if a.showsStatusLine() {
+		frame += lipgloss.PlaceHorizontal(a.width, lipgloss.Center, a.viewStatusLine()) + "\n"
+	}
+	if a.showsTabBar() {
+		frame += lipgloss.PlaceHorizontal(a.width, lipgloss.Center, a.viewTabBar()) + "\n"
+	}
+	frame += boxedContent
 
 	// Place frame at top and controls at bottom of terminal
 	frameHeight := a.height - 1 // Leave room for controls
@@ -105,6 +140,17 @@ func (a *App) renderFrame(content, controls string) string {
 	return frame + "\n" + controlsRendered
 }
 
+// showsStatusLine reports whether the active connection line is drawn above
+// the box; it is hidden on the connection list and form
+func (a *App) showsStatusLine() bool {
+	return a.mode != modeList && a.mode != modeInput
+}
+
+// showsTabBar reports whether the result tab strip is drawn above the box
+func (a *App) showsTabBar() bool {
+	return a.mode == modeQuery
+}
+
 // updateViewportSize recalculates viewport dimensions based on terminal size
 func (a *App) updateViewportSize() {
 	boxWidth := a.width - 4
@@ -117,7 +163,14 @@ func (a *App) updateViewportSize() {
 
 	// Calculate available height for viewport content
 	// Terminal height - logo - controls - box padding - header content inside box
+	// - status line and tab bar when the mode draws them
 	contentHeight := a.height - LogoHeight - ControlsHeight - BoxPadding - BoxHeaderPadding
+	if a.showsStatusLine() {
+		contentHeight -= StatusLineHeight
+	}
+	if a.showsTabBar() {
+		contentHeight -= TabBarHeight
+	}
 	if contentHeight < 5 {
 		contentHeight = 5
 	}
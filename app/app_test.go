@@ -2,7 +2,16 @@ package app
 
 import (
 	"dbsurf/config"
+	"dbsurf/db"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -135,3 +144,656 @@ func TestApp_WindowSizeMsg(t *testing.T) {
 		t.Errorf("After WindowSizeMsg, height = %d, want 40", updated.height)
 	}
 }
+
+func TestViewportSize_OnlyReservesDrawnBars(t *testing.T) {
+	app := New()
+	app.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	listHeight := app.viewport.Height
+
+	app.mode = modeConnected
+	app.View()
+	if got := app.viewport.Height; got != listHeight-StatusLineHeight {
+		t.Errorf("connected viewport height = %d, want %d", got, listHeight-StatusLineHeight)
+	}
+
+	app.mode = modeQuery
+	app.View()
+	if got := app.viewport.Height; got != listHeight-StatusLineHeight-TabBarHeight {
+		t.Errorf("query viewport height = %d, want %d", got, listHeight-StatusLineHeight-TabBarHeight)
+	}
+}
+
+func TestFKNav_CompositeKeys(t *testing.T) {
+	app := New()
+	app.dbType = "postgres"
+	app.queryResult = &db.QueryResult{
+		Columns: []string{"id", "order id", "line_no"},
+		Rows:    [][]string{{"7", "O'1", "2"}},
+	}
+	app.filterResults()
+	app.queryFKRefs = []db.FKReference{
+		{ColumnName: "order id", ConstraintName: "fk_line", ReferencedTable: "public.order_lines", ReferencedColumn: "order"},
+		{ColumnName: "line_no", ConstraintName: "fk_line", ReferencedTable: "public.order_lines", ReferencedColumn: "line_no"},
+	}
+
+	refs := app.fkRefsForColumn("line_no")
+	if len(refs) != 2 {
+		t.Fatalf("fkRefsForColumn() = %v, want both columns of fk_line", refs)
+	}
+	where, err := app.keyWhereClause([]string{"order", "line_no"}, []string{"order id", "line_no"})
+	if want := `"order" = 'O''1' AND "line_no" = '2'`; err != nil || where != want {
+		t.Errorf("keyWhereClause() = %q, %v, want %q", where, err, want)
+	}
+
+	children := groupChildRefs([]db.FKDependency{
+		{TableName: "public.shipments", ColumnName: "order_id", ConstraintName: "fk_ship", ReferencedColumn: "order id"},
+		{TableName: "public.shipments", ColumnName: "line", ConstraintName: "fk_ship", ReferencedColumn: "line_no"},
+		{TableName: "public.notes", ColumnName: "row_id", ConstraintName: "fk_note", ReferencedColumn: "id"},
+	})
+	if len(children) != 2 || len(children[0].columns) != 2 || children[1].String() != "public.notes.row_id -> id" {
+		t.Fatalf("groupChildRefs() = %v, want one entry per constraint", children)
+	}
+	where, err = app.keyWhereClause(children[0].columns, children[0].referenced)
+	if want := `"order_id" = 'O''1' AND "line" = '2'`; err != nil || where != want {
+		t.Errorf("keyWhereClause() for child = %q, %v, want %q", where, err, want)
+	}
+}
+
+func TestNavigation_BackAndForward(t *testing.T) {
+	app := New()
+	app.queryInput.SetValue("SELECT * FROM orders")
+	app.queryResult = &db.QueryResult{Columns: []string{"id"}, Rows: [][]string{{"1"}, {"2"}}}
+	app.filterResults()
+	app.resultCursor = 1
+	app.queryTableName = "orders"
+
+	app.pushNav()
+	app.queryInput.SetValue("SELECT * FROM customers WHERE id = '7'")
+	app.queryResult = &db.QueryResult{Columns: []string{"id"}, Rows: [][]string{{"7"}}}
+	app.filterResults()
+	app.resultCursor = 0
+	app.queryTableName = "customers"
+
+	if got := app.breadcrumb(); got != "orders > customers" {
+		t.Errorf("breadcrumb() = %q, want %q", got, "orders > customers")
+	}
+
+	app.navigateBack()
+
+	if app.queryTableName != "orders" {
+		t.Errorf("after navigateBack, queryTableName = %q, want orders", app.queryTableName)
+	}
+	if app.resultCursor != 1 {
+		t.Errorf("after navigateBack, resultCursor = %d, want 1", app.resultCursor)
+	}
+	if len(app.navBack) != 0 {
+		t.Errorf("after navigateBack, navBack = %d entries, want 0", len(app.navBack))
+	}
+
+	app.navigateForward()
+
+	if app.queryTableName != "customers" {
+		t.Errorf("after navigateForward, queryTableName = %q, want customers", app.queryTableName)
+	}
+	if len(app.navForward) != 0 {
+		t.Errorf("after navigateForward, navForward = %d entries, want 0", len(app.navForward))
+	}
+}
+
+func TestLabels_TruncateByRune(t *testing.T) {
+	query := "SELECT * FROM données_clients WHERE nom = 'é'"
+	label := navEntry{query: query}.label()
+	if !utf8.ValidString(label) || label != "SELECT * FROM donnée…" {
+		t.Errorf("navEntry.label() = %q, want 20 characters and an ellipsis", label)
+	}
+
+	tab := resultTab{name: strings.Repeat("日本", maxTabLabelLen)}
+	if got := tab.label(); !utf8.ValidString(got) || utf8.RuneCountInString(got) != maxTabLabelLen+1 {
+		t.Errorf("resultTab.label() = %q, want %d characters and an ellipsis", got, maxTabLabelLen)
+	}
+
+	if got := truncate("SELECT 'é'", -1); got != "…" {
+		t.Errorf("truncate() with a negative width = %q, want just the ellipsis", got)
+	}
+}
+
+func TestTabs_SwitchPreservesState(t *testing.T) {
+	app := New()
+	app.resetTabs()
+	app.queryInput.SetValue("SELECT * FROM orders")
+	app.queryResult = &db.QueryResult{Columns: []string{"id"}, Rows: [][]string{{"1"}, {"2"}}}
+	app.filterResults()
+	app.resultCursor = 1
+
+	app.newTab()
+	if app.activeTab != 1 || len(app.tabs) != 2 {
+		t.Fatalf("after newTab, activeTab = %d, tabs = %d, want 1, 2", app.activeTab, len(app.tabs))
+	}
+	if app.queryResult != nil || app.queryInput.Value() != "" {
+		t.Error("new tab should start with an empty query and result")
+	}
+
+	app.switchTab(0)
+	if app.queryInput.Value() != "SELECT * FROM orders" {
+		t.Errorf("after switchTab(0), query = %q, want original query", app.queryInput.Value())
+	}
+	if app.resultCursor != 1 {
+		t.Errorf("after switchTab(0), resultCursor = %d, want 1", app.resultCursor)
+	}
+
+	app.closeTab()
+	if len(app.tabs) != 1 || app.queryResult != nil {
+		t.Errorf("after closeTab, tabs = %d, want 1 with the empty tab active", len(app.tabs))
+	}
+}
+
+func TestTabs_KeysLeaveQueryInputBindings(t *testing.T) {
+	app := New()
+	app.mode = modeQuery
+	app.resetTabs()
+	app.queryFocused = true
+	app.queryInput.Focus()
+	app.queryInput.SetValue("SELECT * FROM orders")
+	app.queryInput.CursorEnd()
+	app.newTab()
+	app.queryInput.SetValue("SELECT id FROM users")
+	app.queryInput.CursorEnd()
+
+	app.updateQuery(tea.KeyMsg{Type: tea.KeyCtrlW})
+	if len(app.tabs) != 2 || app.queryInput.Value() != "SELECT id FROM " {
+		t.Errorf("ctrl+w: tabs = %d, query = %q, want the last word deleted", len(app.tabs), app.queryInput.Value())
+	}
+
+	app.updateQuery(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w"), Alt: true})
+	if len(app.tabs) != 1 {
+		t.Errorf("alt+w: tabs = %d, want the tab closed", len(app.tabs))
+	}
+}
+
+func TestSessions_SwitchKeepsPerConnectionState(t *testing.T) {
+	app := New()
+	app.sessions = []session{
+		{conn: config.Connection{Name: "prod-replica", ConnString: "a"}, dbType: "mysql", selectedDatabase: "shop", mode: modeQuery},
+		{conn: config.Connection{Name: "staging", ConnString: "b"}, dbType: "postgres", mode: modeQuery},
+	}
+	app.loadSession(0)
+	app.queryInput.SetValue("SELECT 1")
+
+	app.saveSession()
+	app.loadSession(1)
+	if app.selectedDatabase != "" || app.dbType != "postgres" {
+		t.Errorf("after switching to staging, db = %q (%s), want empty (postgres)", app.selectedDatabase, app.dbType)
+	}
+	if app.queryInput.Value() != "" {
+		t.Errorf("staging query = %q, want empty", app.queryInput.Value())
+	}
+
+	app.saveSession()
+	app.loadSession(0)
+	if app.selectedDatabase != "shop" || app.queryInput.Value() != "SELECT 1" {
+		t.Errorf("after switching back, db = %q query = %q, want shop / SELECT 1", app.selectedDatabase, app.queryInput.Value())
+	}
+	if app.currentConnName() != "prod-replica" {
+		t.Errorf("currentConnName() = %q, want prod-replica", app.currentConnName())
+	}
+}
+
+func TestSessions_OpenDoesNotShareTabs(t *testing.T) {
+	app := New()
+	app.sessions = []session{{conn: config.Connection{Name: "prod", ConnString: "a"}, dbType: "mysql", mode: modeQuery}}
+	app.loadSession(0)
+	app.queryInput.SetValue("SELECT * FROM orders")
+	app.queryResult = &db.QueryResult{Columns: []string{"id"}, Rows: [][]string{{"1"}}}
+	app.filterResults()
+	app.pushNav()
+	app.newTab()
+
+	// The connection fails, but the previous session's state must not leak
+	app.openSession(config.Connection{Name: "broken", ConnString: "not a dsn", DBType: "mysql"})
+	if len(app.tabs) != 1 || app.queryResult != nil || len(app.filteredResultRows) != 0 || len(app.navBack) != 0 {
+		t.Errorf("new session has tabs = %d, result = %v, history = %d, want a single empty tab", len(app.tabs), app.queryResult, len(app.navBack))
+	}
+
+	app.tabs[0].name = "scratch"
+	if got := app.sessions[0].tabs; len(got) != 2 || got[0].name == "scratch" {
+		t.Errorf("prod session tabs = %+v, want its own two tabs", got)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	columns := []string{"id", "name", "total", "created_at", "deleted_at"}
+	rows := [][]string{
+		{"1", "Alice", "9.50", "2024-01-05", "NULL"},
+		{"2", "Bob", "120", "2024-03-01 10:00:00", "2024-04-01"},
+		{"3", "alice smith", "40", "2023-12-31", "NULL"},
+	}
+
+	tests := []struct {
+		filter string
+		want   []string // matching ids
+	}{
+		{"alice", []string{"1", "3"}},
+		{"name=bob", []string{"2"}},
+		{"name=BOB", []string{"2"}}, // case-insensitive, like the pushed-down WHERE
+		{"name != Bob", []string{"1", "3"}},
+		{"total>10", []string{"2", "3"}}, // numeric, not lexicographic
+		{"total <= 40", []string{"1", "3"}},
+		{"created_at >= 2024-01-01", []string{"1", "2"}},
+		{"name~^ali", []string{"1", "3"}},
+		{"deleted_at is null", []string{"1", "3"}},
+		{"deleted_at IS NOT NULL", []string{"2"}},
+		{"total>10 AND deleted_at is null", []string{"3"}},
+		{"id=1 OR id=2 and total>100", []string{"1", "2"}},
+		{"name='alice smith'", []string{"3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := parseFilter(tt.filter, columns)
+			if err != nil {
+				t.Fatalf("parseFilter(%q) error = %v", tt.filter, err)
+			}
+			var got []string
+			for _, row := range rows {
+				if expr.matches(row) {
+					got = append(got, row[0])
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseFilter(%q) matched %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseFilter("nope=1", columns); err == nil {
+		t.Error("parseFilter with unknown column should return error")
+	}
+	if _, err := parseFilter("name~(", columns); err == nil {
+		t.Error("parseFilter with invalid regex should return error")
+	}
+}
+
+func TestFilterWhereClause(t *testing.T) {
+	columns := []string{"id", "name"}
+
+	tests := []struct {
+		filter   string
+		dbType   string
+		want     string
+		wantArgs []any
+	}{
+		{"id>10 AND name!=bob", "postgres", `("id" > $1 AND LOWER(CAST("name" AS TEXT)) <> LOWER($2))`, []any{"10", "bob"}},
+		{"name=Bob", "postgres", `(LOWER(CAST("name" AS TEXT)) = LOWER($1))`, []any{"Bob"}},
+		{"name=Bob", "mysql", "(LOWER(CAST(`name` AS CHAR)) = LOWER(?))", []any{"Bob"}},
+		{"name is null OR id=1", "mysql", "(`name` IS NULL) OR (`id` = ?)", []any{"1"}},
+		{"name~^a", "postgres", `("name" ~* $1)`, []any{"^a"}},
+		{"ali", "sqlserver", "((CAST([id] AS NVARCHAR(MAX)) LIKE @p1 ESCAPE '!' OR CAST([name] AS NVARCHAR(MAX)) LIKE @p2 ESCAPE '!'))", []any{"%ali%", "%ali%"}},
+		{"50%_off!", "postgres", `((CAST("id" AS TEXT) ILIKE $1 ESCAPE '!' OR CAST("name" AS TEXT) ILIKE $2 ESCAPE '!'))`, []any{"%50!%!_off!!%", "%50!%!_off!!%"}},
+	}
+
+	for _, tt := range tests {
+		expr, err := parseFilter(tt.filter, columns)
+		if err != nil {
+			t.Fatalf("parseFilter(%q) error = %v", tt.filter, err)
+		}
+		got, args, err := expr.whereClause(columns, tt.dbType)
+		if err != nil {
+			t.Fatalf("whereClause(%q) error = %v", tt.filter, err)
+		}
+		if got != tt.want {
+			t.Errorf("whereClause(%q, %s) = %s, want %s", tt.filter, tt.dbType, got, tt.want)
+		}
+		if fmt.Sprint(args) != fmt.Sprint(tt.wantArgs) {
+			t.Errorf("whereClause(%q, %s) args = %v, want %v", tt.filter, tt.dbType, args, tt.wantArgs)
+		}
+	}
+
+	expr, _ := parseFilter("name~^a", columns)
+	if _, _, err := expr.whereClause(columns, "sqlserver"); err == nil {
+		t.Error("regex pushdown to sqlserver should return error")
+	}
+}
+
+func TestCycleSort_TypeAwareMultiColumn(t *testing.T) {
+	app := New()
+	app.queryResult = &db.QueryResult{
+		Columns:     []string{"id", "team", "score"},
+		ColumnTypes: []string{"INT4", "VARCHAR", "INT4"},
+		Rows: [][]string{
+			{"1", "b", "9"},
+			{"2", "a", "10"},
+			{"3", "b", "100"},
+			{"4", "a", "NULL"},
+		},
+	}
+	app.filterResults()
+	ids := func() string {
+		var out []string
+		for _, row := range app.filteredResultRows {
+			out = append(out, row[0])
+		}
+		return strings.Join(out, ",")
+	}
+
+	app.fieldCursor = 2
+	app.cycleSort() // score asc: numeric, NULL last
+	if got := ids(); got != "1,2,3,4" {
+		t.Errorf("score asc = %s, want 1,2,3,4", got)
+	}
+	app.cycleSort() // score desc, NULL still last
+	if got := ids(); got != "3,2,1,4" {
+		t.Errorf("score desc = %s, want 3,2,1,4", got)
+	}
+
+	app.clearSort()
+	app.fieldCursor = 1
+	app.cycleSort() // team asc
+	app.fieldCursor = 2
+	app.cycleSort()
+	app.cycleSort() // then score desc
+	if got := ids(); got != "2,4,3,1" {
+		t.Errorf("team asc, score desc = %s, want 2,4,3,1", got)
+	}
+
+	app.cycleSort() // score off
+	app.fieldCursor = 1
+	app.cycleSort()
+	app.cycleSort() // team off
+	if got := ids(); got != "1,2,3,4" {
+		t.Errorf("after turning sorts off = %s, want original order", got)
+	}
+}
+
+func TestComputeColumnStats(t *testing.T) {
+	result := &db.QueryResult{
+		Columns:     []string{"score"},
+		ColumnTypes: []string{"INT4"},
+	}
+	rows := [][]string{{"10"}, {"9"}, {"10"}, {"NULL"}, {"100"}}
+
+	stats := computeColumnStats(result, rows, 0)
+
+	if stats.Total != 5 || stats.Nulls != 1 || stats.Distinct != 3 {
+		t.Errorf("Total/Nulls/Distinct = %d/%d/%d, want 5/1/3", stats.Total, stats.Nulls, stats.Distinct)
+	}
+	if stats.Min != "9" || stats.Max != "100" {
+		t.Errorf("Min/Max = %s/%s, want 9/100", stats.Min, stats.Max)
+	}
+	if stats.Avg != "32.25" {
+		t.Errorf("Avg = %s, want 32.25", stats.Avg)
+	}
+	if stats.MinLength != 1 || stats.MaxLength != 3 {
+		t.Errorf("MinLength/MaxLength = %d/%d, want 1/3", stats.MinLength, stats.MaxLength)
+	}
+	if len(stats.TopValues) == 0 || stats.TopValues[0] != (db.ValueCount{Value: "10", Count: 2}) {
+		t.Errorf("TopValues[0] = %v, want {10 2}", stats.TopValues)
+	}
+}
+
+func TestDiffWatchResults(t *testing.T) {
+	app := New()
+	app.queryPKColumns = []string{"id"}
+	prev := &db.QueryResult{Columns: []string{"id", "count"}, Rows: [][]string{{"1", "5"}, {"2", "7"}}}
+	next := &db.QueryResult{Columns: []string{"id", "count"}, Rows: [][]string{{"2", "8"}, {"1", "5"}, {"3", "1"}}}
+
+	changes := app.diffWatchResults(prev, next)
+
+	if len(changes["1"]) != 0 {
+		t.Errorf("unchanged row 1 marked changed: %v", changes["1"])
+	}
+	if !changes["2"][1] || changes["2"][0] {
+		t.Errorf("row 2 changes = %v, want only count", changes["2"])
+	}
+	if len(changes["3"]) != 2 {
+		t.Errorf("new row 3 changes = %v, want every cell", changes["3"])
+	}
+}
+
+func TestWatch_RunsCapturedQuery(t *testing.T) {
+	app := New()
+	app.mode = modeQuery
+	app.queryFocused = true
+	app.queryInput.Focus()
+	app.queryInput.SetValue("DELETE FROM users")
+	if app.startWatchPrompt() != nil || app.watchPrompting || app.queryErr == nil {
+		t.Error("startWatchPrompt() should refuse a write statement on an unrestricted connection")
+	}
+
+	app.queryErr = nil
+	app.queryInput.SetValue("SELECT * FROM users")
+	app.queryArgs = []any{"7"}
+	app.startWatchPrompt()
+	app.updateWatchPrompt(tea.KeyMsg{Type: tea.KeyEnter})
+	if !app.watching || app.watchQuery != "SELECT * FROM users" || len(app.watchArgs) != 1 {
+		t.Fatalf("watch state = %v %q %v, want the query captured", app.watching, app.watchQuery, app.watchArgs)
+	}
+
+	// Editing the input without running it stops the watch
+	app.updateQueryInput(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(";")})
+	if app.watching || app.watchQuery != "" {
+		t.Error("editing the query should stop watching")
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	before := &db.QueryResult{
+		Columns: []string{"id", "name", "status"},
+		Rows:    [][]string{{"1", "Alice", "active"}, {"2", "Bob", "active"}, {"3", "Cara", "active"}},
+	}
+	after := &db.QueryResult{
+		Columns: []string{"id", "name", "status"},
+		Rows:    [][]string{{"1", "Alice", "active"}, {"2", "Bob", "disabled"}, {"4", "Dan", "active"}},
+	}
+
+	d := diffResults(before, after, []string{"id"})
+	if d.count(diffAdded) != 1 || d.count(diffRemoved) != 1 || d.count(diffChanged) != 1 || d.unchanged != 1 {
+		t.Fatalf("keyed diff = +%d -%d ~%d =%d, want +1 -1 ~1 =1",
+			d.count(diffAdded), d.count(diffRemoved), d.count(diffChanged), d.unchanged)
+	}
+	for _, r := range d.rows {
+		if r.kind == diffChanged && (r.label != "id=2" || !r.changed["status"] || r.changed["name"]) {
+			t.Errorf("changed row = %q %v, want id=2 with only status changed", r.label, r.changed)
+		}
+	}
+
+	d = diffResults(before, after, nil)
+	if d.count(diffAdded) != 2 || d.count(diffRemoved) != 2 || d.count(diffChanged) != 0 || d.unchanged != 1 {
+		t.Errorf("full-row diff = +%d -%d ~%d =%d, want +2 -2 ~0 =1",
+			d.count(diffAdded), d.count(diffRemoved), d.count(diffChanged), d.unchanged)
+	}
+}
+
+func TestStartResultDiff_FallsBackToCurrentPK(t *testing.T) {
+	app := New()
+	app.diffSnapshot = &db.QueryResult{Columns: []string{"id", "name"}, Rows: [][]string{{"1", "Ana"}}}
+	app.queryResult = &db.QueryResult{Columns: []string{"id", "name"}, Rows: [][]string{{"1", "Anaïs"}}}
+	app.queryPKColumns = []string{"id"}
+
+	if err := app.startResultDiff(); err != nil {
+		t.Fatalf("startResultDiff() error = %v", err)
+	}
+	if !slices.Equal(app.diff.keyedBy, []string{"id"}) || app.diff.count(diffChanged) != 1 {
+		t.Errorf("diff keyed by %v with %d changed, want the current PK", app.diff.keyedBy, app.diff.count(diffChanged))
+	}
+}
+
+func TestSortTables_BySizeWithinKind(t *testing.T) {
+	app := New()
+	app.tables = []db.DBObject{
+		{Name: "small", Kind: db.KindTable},
+		{Name: "big", Kind: db.KindTable},
+		{Name: "a_view", Kind: db.KindView},
+		{Name: "medium", Kind: db.KindTable},
+	}
+	app.tableStats = map[string]db.TableStats{
+		"small":  {TotalBytes: 10},
+		"big":    {TotalBytes: 5 << 20},
+		"medium": {TotalBytes: 4096},
+	}
+	app.tableSortKey = slices.Index(tableSortKeys, "total size")
+	app.sortTables()
+
+	var got []string
+	for _, obj := range app.tables {
+		got = append(got, obj.Name)
+	}
+	if want := []string{"big", "medium", "small", "a_view"}; !slices.Equal(got, want) {
+		t.Errorf("sorted tables = %v, want %v", got, want)
+	}
+
+	if got := formatBytes(5 << 20); got != "5.0 MB" {
+		t.Errorf("formatBytes(5 MiB) = %q, want 5.0 MB", got)
+	}
+	if got := formatCount(1234567); got != "1,234,567" {
+		t.Errorf("formatCount(1234567) = %q, want 1,234,567", got)
+	}
+}
+
+func TestPlanTree_CollapseAndHotNodes(t *testing.T) {
+	scan := &db.PlanNode{Label: "Seq Scan", Cost: 80}
+	seek := &db.PlanNode{Label: "Index Scan", Cost: 5}
+	sort := &db.PlanNode{Label: "Sort", Cost: 90, Children: []*db.PlanNode{scan}}
+	root := &db.PlanNode{Label: "Hash Join", Cost: 100, Children: []*db.PlanNode{sort, seek}}
+
+	if got := len(planLines(root, nil)); got != 4 {
+		t.Errorf("planLines() = %d lines, want 4", got)
+	}
+	lines := planLines(root, map[*db.PlanNode]bool{sort: true})
+	if len(lines) != 3 || lines[1].node != sort || lines[2].node != seek || lines[2].depth != 1 {
+		t.Errorf("collapsed planLines() = %+v", lines)
+	}
+
+	// Self costs: scan 80, sort 10, seek 5, join 5
+	hot := hotPlanNodes(root, 2)
+	if len(hot) != 2 || hot[scan] != 1 || hot[sort] != 2 {
+		t.Errorf("hotPlanNodes() = %v, want scan then sort", hot)
+	}
+}
+
+func TestActivityRows_HidesIdle(t *testing.T) {
+	app := New()
+	app.activitySessions = []db.ServerSession{
+		{ID: "1", State: "active"},
+		{ID: "2", State: "idle"},
+		{ID: "3", State: "Sleep"},
+	}
+	if got := len(app.activityRows()); got != 1 {
+		t.Errorf("activityRows() = %d sessions, want 1 with idle hidden", got)
+	}
+	app.activityShowIdle = true
+	if got := len(app.activityRows()); got != 3 {
+		t.Errorf("activityRows() = %d sessions, want 3", got)
+	}
+
+	tests := map[time.Duration]string{
+		850 * time.Millisecond:        "850ms",
+		12 * time.Second:              "12s",
+		5*time.Minute + 3*time.Second: "5m03s",
+		2*time.Hour + 5*time.Minute:   "2h05m",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAudit_ReturnsLogError(t *testing.T) {
+	// A file in place of the home directory makes the log unwritable
+	home := filepath.Join(t.TempDir(), "home")
+	os.WriteFile(home, nil, 0600)
+	t.Setenv("HOME", home)
+	app := New()
+
+	if err := app.audit("DELETE FROM users", 1, nil); err == nil || !strings.Contains(err.Error(), "audit log") {
+		t.Errorf("audit() error = %v, want the log failure", err)
+	}
+	if err := app.audit("DELETE FROM users", 0, errors.New("syntax error")); err != nil {
+		t.Errorf("audit() of a failed statement error = %v, want nil", err)
+	}
+	if app.queryErr != nil {
+		t.Errorf("audit() set queryErr = %v, callers report it", app.queryErr)
+	}
+}
+
+func TestRunStatement_SafetyLevels(t *testing.T) {
+	// Refused writes are audited; keep the log out of the real home
+	t.Setenv("HOME", t.TempDir())
+	app := New()
+	app.sessions = []session{{conn: config.Connection{Name: "prod", ConnString: "a", Safety: config.SafetyReadOnly}}}
+	app.activeSession = 0
+
+	app.runStatement("DELETE FROM users", "users")
+	if app.queryErr == nil || !strings.Contains(app.queryErr.Error(), "read-only") {
+		t.Errorf("read-only runStatement() error = %v, want read-only error", app.queryErr)
+	}
+	if err := app.startRecordDelete(); err == nil {
+		t.Error("startRecordDelete() on a read-only connection should fail")
+	}
+
+	app.queryErr = nil
+	app.sessions[0].conn.Safety = config.SafetyConfirmWrites
+	app.runStatement("UPDATE users SET name = 'x'", "users")
+	if !app.writeConfirming || app.pendingWriteSQL != "UPDATE users SET name = 'x'" {
+		t.Errorf("confirm-writes runStatement() should wait for confirmation, confirming = %v", app.writeConfirming)
+	}
+	app.updateWriteConfirm(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.writeConfirming || app.pendingWriteSQL != "" || app.queryErr != nil {
+		t.Error("cancelling the write confirmation should clear it without running the statement")
+	}
+
+	// EXPLAIN ANALYZE executes the statement, so it asks too
+	app.dbType = "postgres"
+	app.queryInput.SetValue("DELETE FROM users")
+	app.startExplain(true)
+	if !app.writeConfirming || !app.pendingWriteExplain || app.showingExplain {
+		t.Errorf("EXPLAIN ANALYZE of a write should wait for confirmation, confirming = %v", app.writeConfirming)
+	}
+	app.updateWriteConfirm(tea.KeyMsg{Type: tea.KeyEsc})
+	if app.writeConfirming || app.pendingWriteExplain {
+		t.Error("cancelling should clear the pending EXPLAIN ANALYZE")
+	}
+}
+
+func TestImpactKey_TypedConfirmation(t *testing.T) {
+	app := New()
+	app.dbType = "postgres"
+	app.queryTableName = "public.orders"
+	app.impactCount = 250
+	app.impactTyping = true
+	app.impactInput.Focus()
+
+	if key, _ := app.impactKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); key != "" {
+		t.Errorf("impactKey(y) while typing = %q, want the key sent to the input", key)
+	}
+	app.impactInput.SetValue("249")
+	if key, _ := app.impactKey(tea.KeyMsg{Type: tea.KeyEnter}); key != "" {
+		t.Errorf("impactKey(enter) with the wrong count = %q, want no confirmation", key)
+	}
+	for _, typed := range []string{"250", "orders", "public.orders"} {
+		app.impactInput.SetValue(typed)
+		if key, _ := app.impactKey(tea.KeyMsg{Type: tea.KeyEnter}); key != "y" {
+			t.Errorf("impactKey(enter) after typing %q = %q, want y", typed, key)
+		}
+	}
+	if key, _ := app.impactKey(tea.KeyMsg{Type: tea.KeyEsc}); key != "esc" {
+		t.Errorf("impactKey(esc) = %q, want esc", key)
+	}
+
+	// q is typed into the confirmation rather than quitting
+	app.mode = modeQuery
+	app.editConfirming = true
+	app.impactInput.SetValue("")
+	app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if app.impactInput.Value() != "q" {
+		t.Errorf("q while typing a confirmation: input = %q, want it typed rather than quitting", app.impactInput.Value())
+	}
+	app.editConfirming = false
+
+	// A failed count refuses plain confirmation
+	app.impactTyping = false
+	app.impactErr = fmt.Errorf("could not count affected rows")
+	if key, _ := app.impactKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}); key != "" {
+		t.Errorf("impactKey(y) after a failed count = %q, want no confirmation", key)
+	}
+}
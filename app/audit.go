@@ -0,0 +1,113 @@
+// audit.go records write statements (typed statements, inline edits,
+// deletes, session cancel/kill) in the audit log and shows recent entries
+// in a viewer opened from the connection list.
+package app
+
+import (
+	"dbsurf/audit"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const auditViewLimit = 500
+
+// audit appends a write to the audit log. rows is -1 when unknown. A failure
+// to write the log is returned unless the statement itself failed; callers
+// show it after their success path so it is not cleared.
+func (a *App) audit(statement string, rows int64, err error) error {
+	e := audit.Entry{
+		Connection: a.currentConn().Name,
+		Database:   a.selectedDatabase,
+		Statement:  statement,
+		Rows:       rows,
+		Outcome:    audit.OutcomeOK,
+	}
+	if err != nil {
+		e.Outcome = audit.OutcomeError
+		e.Error = err.Error()
+		if errors.Is(err, errReadOnly) {
+			e.Outcome = audit.OutcomeRefused
+		}
+	}
+	if logErr := audit.Append(e); logErr != nil && err == nil {
+		return fmt.Errorf("audit log: %w", logErr)
+	}
+	return nil
+}
+
+func (a *App) startAuditLog() {
+	a.auditEntries, a.auditErr = audit.Recent(auditViewLimit)
+	a.auditCursor = 0
+	a.showingAudit = true
+}
+
+func (a *App) updateAuditLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.showingAudit = false
+	case "j", "down":
+		a.auditCursor = moveCursor(a.auditCursor, 1, len(a.auditEntries))
+	case "k", "up":
+		a.auditCursor = moveCursor(a.auditCursor, -1, len(a.auditEntries))
+	case "r":
+		a.startAuditLog()
+	}
+	return a, nil
+}
+
+func (a *App) viewAuditLog() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Audit log"))
+	if path, err := audit.Path(); err == nil {
+		b.WriteString(dimStyle.Render("  " + path))
+	}
+	b.WriteString("\n\n")
+
+	if a.auditErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.auditErr.Error()))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+	if len(a.auditEntries) == 0 {
+		b.WriteString(dimStyle.Render("No writes recorded yet"))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+
+	width := max(20, a.viewport.Width)
+	var lines []string
+	for i, e := range a.auditEntries {
+		rows := "?"
+		if e.Rows >= 0 {
+			rows = fmt.Sprint(e.Rows)
+		}
+		line := fmt.Sprintf("%s %-12.12s %-7s %5s  %s", e.Time.Format("01-02 15:04:05"), e.Connection, e.Outcome, rows, oneLine(e.Statement))
+		line = truncate(line, width-3)
+		switch {
+		case i == a.auditCursor:
+			lines = append(lines, "> "+selectedStyle.Render(line))
+		case e.Outcome != audit.OutcomeOK:
+			lines = append(lines, "  "+errorStyle.Render(line))
+		default:
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	height := max(3, a.viewport.Height-6)
+	offset := max(0, min(a.auditCursor-height/2, len(lines)-height))
+	end := min(len(lines), offset+height)
+	b.WriteString(strings.Join(lines[offset:end], "\n"))
+	b.WriteString("\n\n")
+
+	e := a.auditEntries[a.auditCursor]
+	b.WriteString(valueStyle.Render(fmt.Sprintf("%s by %s on %s / %s", e.Time.Format("2006-01-02 15:04:05"), e.User, e.Connection, e.Database)))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(e.Statement))
+	if e.Error != "" {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render(e.Error))
+	}
+
+	return a.renderFrame(b.String(), "j/k: navigate • r: reload • esc: close")
+}
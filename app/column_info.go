@@ -0,0 +1,197 @@
+// column_info.go implements the column info overlay opened with "?" from the
+// table list and from query results. It has three sub-views: columns (with
+// keys, FK targets, identity/generated flags and collation), indexes, and
+// unique/check constraints. tab cycles between them.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	columnInfoTabColumns = iota
+	columnInfoTabIndexes
+	columnInfoTabConstraints
+	columnInfoTabCount
+)
+
+// openColumnInfo loads the catalog metadata for tableName and shows the
+// overlay with selectColumn highlighted, if present. It reports whether any
+// columns were found.
+func (a *App) openColumnInfo(tableName, selectColumn string) bool {
+	info, err := db.GetColumnInfo(a.db, a.selectedDatabase, tableName, a.dbType)
+	if err != nil || len(info) == 0 {
+		return false
+	}
+
+	a.columnInfoFKs = make(map[string]string)
+	if refs, err := db.GetOutgoingFKs(a.db, a.selectedDatabase, tableName, a.dbType); err == nil {
+		for _, ref := range refs {
+			a.columnInfoFKs[ref.ColumnName] = ref.ReferencedTable + "." + ref.ReferencedColumn
+		}
+	}
+	a.columnInfoIndexes, a.columnInfoMetaErr = db.GetIndexes(a.db, a.selectedDatabase, tableName, a.dbType)
+	constraints, err := db.GetConstraints(a.db, a.selectedDatabase, tableName, a.dbType)
+	a.columnInfoConstraints = constraints
+	if a.columnInfoMetaErr == nil {
+		a.columnInfoMetaErr = err
+	}
+
+	a.columnInfoData = info
+	a.columnInfoFilter = ""
+	a.columnInfoSearchInput.SetValue("")
+	a.filteredColumnInfo = info
+	a.columnInfoTab = columnInfoTabColumns
+	tableHeight := min(len(info), 15)
+	a.columnInfoTable = buildColumnInfoTable(info, a.columnInfoFKs, tableHeight)
+	for i, col := range info {
+		if col.Name == selectColumn {
+			a.columnInfoTable.SetCursor(i)
+			break
+		}
+	}
+	a.showingColumnInfo = true
+	return true
+}
+
+func (a *App) updateColumnInfo(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.columnInfoSearching {
+		switch msg.String() {
+		case "esc", "enter":
+			a.columnInfoSearching = false
+			a.columnInfoSearchInput.Blur()
+			return a, nil
+		}
+		var cmd tea.Cmd
+		a.columnInfoSearchInput, cmd = a.columnInfoSearchInput.Update(msg)
+		a.columnInfoFilter = a.columnInfoSearchInput.Value()
+		a.filterAndRebuildColumnInfo()
+		return a, cmd
+	}
+
+	switch msg.String() {
+	case "esc":
+		if a.columnInfoFilter != "" {
+			a.columnInfoFilter = ""
+			a.columnInfoSearchInput.SetValue("")
+			a.filterAndRebuildColumnInfo()
+			return a, nil
+		}
+		a.showingColumnInfo = false
+		return a, nil
+	case "?", "q":
+		a.showingColumnInfo = false
+		return a, nil
+	case "tab":
+		a.columnInfoTab = (a.columnInfoTab + 1) % columnInfoTabCount
+		return a, nil
+	case "shift+tab":
+		a.columnInfoTab = (a.columnInfoTab + columnInfoTabCount - 1) % columnInfoTabCount
+		return a, nil
+	case "d":
+		a.showTableDDL(a.queryTableName)
+		return a, nil
+	case "/":
+		if a.columnInfoTab == columnInfoTabColumns {
+			a.columnInfoSearching = true
+			a.columnInfoSearchInput.Focus()
+			return a, textinput.Blink
+		}
+		return a, nil
+	}
+
+	if a.columnInfoTab != columnInfoTabColumns {
+		return a, nil
+	}
+	var cmd tea.Cmd
+	a.columnInfoTable, cmd = a.columnInfoTable.Update(msg)
+	return a, cmd
+}
+
+func (a *App) viewColumnInfo() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Column Info: " + a.queryTableName))
+	b.WriteString("\n\n")
+
+	labels := []string{
+		"Columns",
+		fmt.Sprintf("Indexes (%d)", len(a.columnInfoIndexes)),
+		fmt.Sprintf("Constraints (%d)", len(a.columnInfoConstraints)),
+	}
+	for i, label := range labels {
+		if i == a.columnInfoTab {
+			b.WriteString(activeTabStyle.Render(label))
+		} else {
+			b.WriteString(inactiveTabStyle.Render(label))
+		}
+		b.WriteString("  ")
+	}
+	b.WriteString("\n\n")
+
+	switch a.columnInfoTab {
+	case columnInfoTabColumns:
+		if a.columnInfoSearching {
+			b.WriteString(inputLabelStyle.Render("Filter: "))
+			b.WriteString(a.columnInfoSearchInput.View())
+		} else if a.columnInfoFilter != "" {
+			b.WriteString(dimStyle.Render("Filter: " + a.columnInfoFilter + " (esc to clear)"))
+		} else {
+			b.WriteString(dimStyle.Render("Filter: press / to filter"))
+		}
+		b.WriteString("\n\n")
+
+		if len(a.filteredColumnInfo) > 0 {
+			b.WriteString(a.columnInfoTable.View())
+		} else {
+			b.WriteString(dimStyle.Render("No columns match filter"))
+		}
+		return a.renderFrame(b.String(), "j/k: navigate • /: filter • tab: indexes/constraints • d: DDL • esc/?: close")
+
+	case columnInfoTabIndexes:
+		a.writeColumnInfoMetaErr(&b)
+		if len(a.columnInfoIndexes) == 0 {
+			b.WriteString(dimStyle.Render("No secondary indexes"))
+		}
+		for _, idx := range a.columnInfoIndexes {
+			kind := ""
+			if idx.Unique {
+				kind = "UNIQUE"
+			}
+			b.WriteString(fmt.Sprintf("  %s %s %s %s\n",
+				selectedStyle.Render(idx.Name),
+				editingStyle.Render(kind),
+				dimStyle.Render(idx.Type),
+				valueStyle.Render("("+strings.Join(idx.Columns, ", ")+")")))
+		}
+
+	case columnInfoTabConstraints:
+		a.writeColumnInfoMetaErr(&b)
+		if len(a.columnInfoConstraints) == 0 {
+			b.WriteString(dimStyle.Render("No unique or check constraints"))
+		}
+		for _, c := range a.columnInfoConstraints {
+			detail := c.Definition
+			if detail == "" {
+				detail = "(" + strings.Join(c.Columns, ", ") + ")"
+			}
+			b.WriteString(fmt.Sprintf("  %s %s %s\n",
+				selectedStyle.Render(c.Name),
+				editingStyle.Render(c.Type),
+				valueStyle.Render(detail)))
+		}
+	}
+
+	return a.renderFrame(b.String(), "tab: next view • d: DDL • esc/?: close")
+}
+
+func (a *App) writeColumnInfoMetaErr(b *strings.Builder) {
+	if a.columnInfoMetaErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.columnInfoMetaErr.Error()))
+		b.WriteString("\n\n")
+	}
+}
@@ -0,0 +1,187 @@
+// column_profile.go shows a statistics panel for the column under the field
+// cursor: distinct and null counts, min/max, average for numeric columns,
+// string length range and the most frequent values as a text histogram.
+// Stats are computed from the loaded rows, or from the whole table on demand.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	profileTopN          = 5
+	profileHistogramSize = 20
+)
+
+var histogramStyle = lipgloss.NewStyle().Foreground(ColorPrimary)
+
+// computeColumnStats profiles column idx of rows in memory
+func computeColumnStats(result *db.QueryResult, rows [][]string, idx int) *db.ColumnStats {
+	stats := &db.ColumnStats{Column: result.Columns[idx], Total: len(rows), MinLength: -1}
+	typ := ""
+	if idx < len(result.ColumnTypes) {
+		typ = result.ColumnTypes[idx]
+	}
+
+	counts := make(map[string]int)
+	numeric := typ == "" || isNumericType(typ)
+	var sum float64
+	for _, row := range rows {
+		val := row[idx]
+		counts[val]++
+		if val == "NULL" {
+			stats.Nulls++
+			continue
+		}
+
+		if stats.Min == "" || compareTyped(val, stats.Min, typ) < 0 {
+			stats.Min = val
+		}
+		if stats.Max == "" || compareTyped(val, stats.Max, typ) > 0 {
+			stats.Max = val
+		}
+		n := len([]rune(val))
+		if stats.MinLength == -1 || n < stats.MinLength {
+			stats.MinLength = n
+		}
+		stats.MaxLength = max(stats.MaxLength, n)
+
+		if numeric {
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				numeric = false
+				continue
+			}
+			sum += f
+		}
+	}
+	stats.MinLength = max(stats.MinLength, 0)
+
+	nonNull := stats.Total - stats.Nulls
+	if numeric && nonNull > 0 {
+		stats.Avg = strconv.FormatFloat(sum/float64(nonNull), 'f', -1, 64)
+	}
+
+	for val, count := range counts {
+		stats.Distinct++
+		stats.TopValues = append(stats.TopValues, db.ValueCount{Value: val, Count: count})
+	}
+	if _, hasNull := counts["NULL"]; hasNull {
+		// COUNT(DISTINCT) ignores NULL; match the database's definition
+		stats.Distinct--
+	}
+	slices.SortFunc(stats.TopValues, func(x, y db.ValueCount) int {
+		if x.Count != y.Count {
+			return y.Count - x.Count
+		}
+		return strings.Compare(x.Value, y.Value)
+	})
+	if len(stats.TopValues) > profileTopN {
+		stats.TopValues = stats.TopValues[:profileTopN]
+	}
+	return stats
+}
+
+// startColumnProfile opens the profile panel for the column under the cursor
+func (a *App) startColumnProfile() {
+	if a.queryResult == nil || a.fieldCursor >= len(a.queryResult.Columns) {
+		return
+	}
+	a.profileStats = computeColumnStats(a.queryResult, a.filteredResultRows, a.fieldCursor)
+	a.profileSource = fmt.Sprintf("%d loaded rows", len(a.filteredResultRows))
+	a.profileErr = nil
+	a.showingProfile = true
+}
+
+// profileWholeTable recomputes the profile with aggregate queries on the table
+func (a *App) profileWholeTable() {
+	if a.queryTableName == "" {
+		a.profileErr = fmt.Errorf("could not determine table name from query")
+		return
+	}
+	idx := a.fieldCursor
+	typ := ""
+	if idx < len(a.queryResult.ColumnTypes) {
+		typ = a.queryResult.ColumnTypes[idx]
+	}
+	stats, err := db.ProfileColumn(a.db, a.selectedDatabase, a.queryTableName,
+		a.queryResult.Columns[idx], a.dbType, isNumericType(typ), profileTopN)
+	if err != nil {
+		a.profileErr = err
+		return
+	}
+	a.profileStats = stats
+	a.profileSource = "whole table " + a.queryTableName
+	a.profileErr = nil
+}
+
+func (a *App) updateColumnProfile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "p":
+		a.showingProfile = false
+		a.profileStats = nil
+	case "a":
+		a.profileWholeTable()
+	}
+	return a, nil
+}
+
+func (a *App) viewColumnProfile() string {
+	var b strings.Builder
+	stats := a.profileStats
+	b.WriteString(selectedStyle.Render("Column Profile: " + stats.Column))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("from " + a.profileSource))
+	b.WriteString("\n\n")
+
+	nullPct := 0.0
+	if stats.Total > 0 {
+		nullPct = float64(stats.Nulls) / float64(stats.Total) * 100
+	}
+	field := func(label, value string) {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("%-10s", label)))
+		b.WriteString(valueStyle.Render(value))
+		b.WriteString("\n")
+	}
+	field("Rows", strconv.Itoa(stats.Total))
+	field("Distinct", strconv.Itoa(stats.Distinct))
+	field("Nulls", fmt.Sprintf("%d (%.1f%%)", stats.Nulls, nullPct))
+	field("Min", stats.Min)
+	field("Max", stats.Max)
+	if stats.Avg != "" {
+		field("Avg", stats.Avg)
+	}
+	field("Length", fmt.Sprintf("%d - %d", stats.MinLength, stats.MaxLength))
+
+	if len(stats.TopValues) > 0 {
+		b.WriteString("\n")
+		b.WriteString(selectedStyle.Render(fmt.Sprintf("Top %d values", len(stats.TopValues))))
+		b.WriteString("\n")
+		top := stats.TopValues[0].Count
+		for _, vc := range stats.TopValues {
+			bar := 1
+			if top > 0 {
+				bar = max(1, vc.Count*profileHistogramSize/top)
+			}
+			b.WriteString(fmt.Sprintf("%-21s ", truncate(vc.Value, 20)))
+			b.WriteString(histogramStyle.Render(strings.Repeat("█", bar)))
+			b.WriteString(dimStyle.Render(fmt.Sprintf(" %d", vc.Count)))
+			b.WriteString("\n")
+		}
+	}
+
+	if a.profileErr != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render("Error: " + a.profileErr.Error()))
+	}
+
+	controls := "a: profile whole table • esc/p: close"
+	return a.renderFrame(b.String(), controls)
+}
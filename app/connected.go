@@ -1,5 +1,7 @@
 // connected.go handles the database selection view after connecting to a server.
-// It displays available databases and allows filtering and selection.
+// It displays available databases and allows filtering and selection. Postgres
+// cannot switch databases with USE, so selecting another database reconnects.
+// The server activity monitor is opened from here.
 package app
 
 import (
@@ -38,34 +40,51 @@ func (a *App) updateConnected(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	switch msg.String() {
 	case "esc":
-		if a.db != nil {
-			a.db.Close()
-			a.db = nil
-		}
+		// Leave the session open so it can be switched back to
+		a.saveSession()
 		a.mode = modeList
 		return a, nil
+	case "ctrl+s":
+		if len(a.sessions) > 0 {
+			a.startSessionSwitcher()
+		}
+		return a, nil
 	case "/":
 		a.dbSearching = true
 		a.dbSearchInput.Focus()
 		return a, textinput.Blink
+	case "a":
+		if a.dbErr == nil {
+			return a, a.startActivity()
+		}
 	case "j", "down":
 		a.dbCursor = moveCursor(a.dbCursor, 1, len(a.filteredDatabases))
 	case "k", "up":
 		a.dbCursor = moveCursor(a.dbCursor, -1, len(a.filteredDatabases))
 	case "enter":
 		if len(a.filteredDatabases) > 0 {
-			a.selectedDatabase = a.filteredDatabases[a.dbCursor]
-			// Skip UseDatabase for SQL Server - handled via query prepend
-			if a.dbType != "sqlserver" {
-				if err := db.UseDatabase(a.db, a.selectedDatabase, a.dbType); err != nil {
+			name := a.filteredDatabases[a.dbCursor]
+			switch a.dbType {
+			case "postgres":
+				if err := a.reconnectDatabase(name); err != nil {
+					a.dbSwitchErr = err
+					return a, nil
+				}
+			case "sqlserver":
+				// Skip UseDatabase for SQL Server - handled via query prepend
+			default:
+				if err := db.UseDatabase(a.db, name, a.dbType); err != nil {
 					a.queryErr = err
 				}
 			}
+			a.selectedDatabase = name
+			a.dbSwitchErr = nil
 			a.queryInput.Reset()
 			a.queryInput.Focus()
 			a.queryFocused = true
 			a.queryResult = nil
 			a.queryErr = nil
+			a.resetTabs()
 			a.mode = modeQuery
 			return a, textinput.Blink
 		}
@@ -73,14 +92,38 @@ func (a *App) updateConnected(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// reconnectDatabase replaces the active Postgres connection with one to dbName
+func (a *App) reconnectDatabase(dbName string) error {
+	if dbName == a.selectedDatabase || a.activeSession < 0 {
+		return nil
+	}
+	saved := a.sessions[a.activeSession].conn
+	opts, err := a.connectOptions(saved)
+	if err != nil {
+		return err
+	}
+	opts.Database = dbName
+	conn, err := db.ConnectWith(saved.ConnString, opts)
+	if err != nil {
+		return err
+	}
+	a.db.Close()
+	a.db = conn
+	a.sessions[a.activeSession].db = conn
+	return nil
+}
+
 func (a *App) viewConnected() string {
 	var content string
-	conn := a.config.Connections[a.cursor]
 
 	if a.dbErr != nil {
 		content = "Connection failed: " + a.dbErr.Error()
 	} else {
-		content = "Connected to " + conn.Name + "\n\n"
+		content = "Connected to " + a.currentConnName() + "\n\n"
+
+		if a.dbSwitchErr != nil {
+			content += errorStyle.Render("Error: "+a.dbSwitchErr.Error()) + "\n\n"
+		}
 
 		if a.dbSearching {
 			content += inputLabelStyle.Render("Search: ") + a.dbSearchInput.View() + "\n\n"
@@ -96,6 +139,9 @@ func (a *App) viewConnected() string {
 			for i, db := range a.filteredDatabases {
 				prefix := "  "
 				line := db
+				if db == a.selectedDatabase {
+					line += dimStyle.Render(" (current)")
+				}
 				if i == a.dbCursor {
 					prefix = "> "
 					line = selectedStyle.Render(line)
@@ -110,7 +156,7 @@ func (a *App) viewConnected() string {
 		}
 	}
 
-	controls := "j/k: navigate • /: search • enter: select • esc: back • q: quit"
+	controls := "j/k: navigate • /: search • enter: select • a: activity • ctrl+s: connections • esc: back • q: quit"
 
 	return a.renderFrame(content, controls)
 }
@@ -13,6 +13,8 @@ const (
 	ControlsHeight   = 2  // Controls line + spacing
 	BoxPadding       = 4  // Border (2) + padding (2) vertical
 	BoxHeaderPadding = 5  // Header lines inside box (title + search + label)
+	TabBarHeight     = 1  // Result tab strip above the box in query mode
+	StatusLineHeight = 1  // Active connection line above the box
 )
 
 // Color palette
@@ -0,0 +1,229 @@
+// explain.go shows the execution plan of the current query as a collapsible
+// tree. Each node lists its estimated cost and rows, plus actual rows and
+// time when the plan was analyzed, and the most expensive nodes by their own
+// (exclusive) cost or time are highlighted.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const explainHotNodes = 3
+
+// planLine is one visible row of the plan tree
+type planLine struct {
+	node  *db.PlanNode
+	depth int
+}
+
+func (a *App) startExplain(analyze bool) {
+	query := strings.TrimSpace(a.queryInput.Value())
+	if query == "" {
+		return
+	}
+	analyze = analyze && a.dbType == "postgres"
+	if analyze {
+		// ANALYZE executes the statement; the rollback would undo it, but
+		// it still runs under the connection's safety level. Read-only
+		// connections fall back to the plain plan.
+		confirm, err := a.checkWrite(query)
+		if err != nil {
+			analyze = false
+		} else if confirm {
+			a.showingExplain = false
+			a.confirmWrite(query, "", true, a.queryArgs)
+			return
+		}
+	}
+	a.runExplain(query, analyze, a.queryArgs...)
+}
+
+// runExplain fetches and shows the plan of a query that passed the safety
+// check
+func (a *App) runExplain(query string, analyze bool, args ...any) {
+	a.explainQuery = query
+	a.explainAnalyze = analyze
+	a.explainPlan, a.explainErr = db.Explain(a.db, a.selectedDatabase, query, a.dbType, analyze, args...)
+	a.explainCursor = 0
+	a.explainCollapsed = make(map[*db.PlanNode]bool)
+	a.explainHot = nil
+	if a.explainPlan != nil {
+		a.explainHot = hotPlanNodes(a.explainPlan, explainHotNodes)
+	}
+	a.showingExplain = true
+}
+
+// hotPlanNodes ranks the n most expensive nodes by self time when the plan
+// was analyzed, otherwise by self cost. Ranks start at 1.
+func hotPlanNodes(root *db.PlanNode, n int) map[*db.PlanNode]int {
+	metric := (*db.PlanNode).SelfCost
+	if root.HasActual {
+		metric = (*db.PlanNode).SelfTime
+	}
+	var nodes []*db.PlanNode
+	root.Walk(func(node *db.PlanNode) {
+		if metric(node) > 0 {
+			nodes = append(nodes, node)
+		}
+	})
+	slices.SortStableFunc(nodes, func(x, y *db.PlanNode) int {
+		return cmpFloat(metric(y), metric(x))
+	})
+
+	hot := make(map[*db.PlanNode]int)
+	for i, node := range nodes[:min(n, len(nodes))] {
+		hot[node] = i + 1
+	}
+	return hot
+}
+
+func cmpFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// planLines flattens the plan into visible rows, skipping the children of
+// collapsed nodes
+func planLines(root *db.PlanNode, collapsed map[*db.PlanNode]bool) []planLine {
+	var lines []planLine
+	var walk func(node *db.PlanNode, depth int)
+	walk = func(node *db.PlanNode, depth int) {
+		lines = append(lines, planLine{node, depth})
+		if collapsed[node] {
+			return
+		}
+		for _, c := range node.Children {
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	return lines
+}
+
+func (a *App) updateExplain(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.explainErr != nil || a.explainPlan == nil {
+		if msg.String() == "esc" {
+			a.showingExplain = false
+		}
+		return a, nil
+	}
+
+	lines := planLines(a.explainPlan, a.explainCollapsed)
+	node := lines[min(a.explainCursor, len(lines)-1)].node
+	switch msg.String() {
+	case "esc":
+		a.showingExplain = false
+	case "j", "down":
+		a.explainCursor = moveCursor(a.explainCursor, 1, len(lines))
+	case "k", "up":
+		a.explainCursor = moveCursor(a.explainCursor, -1, len(lines))
+	case "enter", " ":
+		if len(node.Children) > 0 {
+			a.explainCollapsed[node] = !a.explainCollapsed[node]
+		}
+	case "h", "left":
+		if len(node.Children) > 0 {
+			a.explainCollapsed[node] = true
+		}
+	case "l", "right":
+		delete(a.explainCollapsed, node)
+	case "a":
+		if a.dbType == "postgres" {
+			a.startExplain(!a.explainAnalyze)
+		}
+	}
+	return a, nil
+}
+
+// planNodeStats formats the numbers shown after a node's label
+func planNodeStats(node *db.PlanNode) string {
+	s := fmt.Sprintf("cost=%.2f rows=%s", node.Cost, formatCount(int64(node.Rows)))
+	if node.HasActual {
+		s += fmt.Sprintf(" actual rows=%s time=%.3fms", formatCount(int64(node.ActualRows)), node.ActualTime)
+	}
+	return s
+}
+
+func (a *App) viewExplain() string {
+	var b strings.Builder
+	title := "Plan"
+	if a.explainAnalyze {
+		title += " (analyzed)"
+	} else {
+		title += " (estimated)"
+	}
+	b.WriteString(selectedStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(truncate(a.explainQuery, max(10, a.viewport.Width)-1)))
+	b.WriteString("\n\n")
+
+	if a.explainErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.explainErr.Error()))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+
+	lines := planLines(a.explainPlan, a.explainCollapsed)
+	a.explainCursor = min(a.explainCursor, len(lines)-1)
+	var rendered []string
+	for i, line := range lines {
+		node := line.node
+		marker := "  "
+		if len(node.Children) > 0 {
+			marker = "▾ "
+			if a.explainCollapsed[node] {
+				marker = "▸ "
+			}
+		}
+		label := node.Label
+		switch rank := a.explainHot[node]; {
+		case i == a.explainCursor:
+			label = selectedStyle.Render(label)
+		case rank == 1:
+			label = errorStyle.Render(label)
+		case rank > 1:
+			label = editingStyle.Render(label)
+		}
+		prefix := "  "
+		if i == a.explainCursor {
+			prefix = "> "
+		}
+		rendered = append(rendered, prefix+strings.Repeat("  ", line.depth)+marker+label+" "+dimStyle.Render(planNodeStats(node)))
+	}
+
+	// Leave room below the tree for the selected node's details
+	node := lines[a.explainCursor].node
+	details := []string{fmt.Sprintf("Self cost: %.2f", node.SelfCost())}
+	if node.HasActual {
+		details = append(details, fmt.Sprintf("Self time: %.3fms", node.SelfTime()))
+	}
+	if rank := a.explainHot[node]; rank > 0 {
+		details = append(details, fmt.Sprintf("#%d most expensive node", rank))
+	}
+	details = append(details, node.Details...)
+
+	height := max(3, a.viewport.Height-len(details)-2)
+	offset := max(0, min(a.explainCursor-height/2, len(rendered)-height))
+	end := min(len(rendered), offset+height)
+	b.WriteString(strings.Join(rendered[offset:end], "\n"))
+	b.WriteString("\n\n")
+	for _, d := range details {
+		b.WriteString(valueStyle.Render(d) + "\n")
+	}
+
+	controls := "j/k: navigate • enter: collapse/expand • h/l: collapse/expand"
+	if a.dbType == "postgres" {
+		controls += " • a: toggle ANALYZE"
+	}
+	controls += " • esc: close"
+	return a.renderFrame(b.String(), controls)
+}
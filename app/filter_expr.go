@@ -0,0 +1,332 @@
+// filter_expr.go parses and evaluates the result filter syntax. A filter is
+// either plain text, matched against every column, or a set of column
+// conditions (col=value, col!=value, col>10, col~regex, col is null) combined
+// with AND/OR, where AND binds tighter than OR. Comparisons are numeric or
+// chronological when both sides parse as numbers or dates, otherwise
+// case-insensitive, both here and when pushed down as a WHERE clause.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const filterHint = "col=v • col!=v • col>10 • col~regex • col is null • AND/OR"
+
+var (
+	filterCondRe   = regexp.MustCompile(`^([\w.$]+)\s*(!=|>=|<=|=|>|<|~)\s*(.*)$`)
+	filterIsNullRe = regexp.MustCompile(`(?i)^([\w.$]+)\s+is\s+(not\s+)?null$`)
+)
+
+// likeEscaper makes LIKE match a filter value literally. ! is the ESCAPE
+// character since no engine gives it a meaning in string literals; [ is
+// escaped for SQL Server, where it starts a character class.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "[", "![")
+
+// dateLayouts are tried in order when comparing values chronologically
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// filterCond is a single condition. An empty column means free text.
+type filterCond struct {
+	column   string
+	colIndex int
+	op       string // =, !=, >, >=, <, <=, ~, is null, is not null
+	value    string
+	re       *regexp.Regexp
+}
+
+// filterExpr is a disjunction of conjunctions: (a AND b) OR (c)
+type filterExpr struct {
+	groups [][]filterCond
+}
+
+// parseFilter parses text against the given result columns
+func parseFilter(text string, columns []string) (*filterExpr, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	expr := &filterExpr{}
+	for _, orPart := range splitKeyword(text, "OR") {
+		var group []filterCond
+		for _, andPart := range splitKeyword(orPart, "AND") {
+			cond, err := parseFilterCond(strings.TrimSpace(andPart), columns)
+			if err != nil {
+				return nil, err
+			}
+			group = append(group, cond)
+		}
+		expr.groups = append(expr.groups, group)
+	}
+	return expr, nil
+}
+
+func parseFilterCond(text string, columns []string) (filterCond, error) {
+	if text == "" {
+		return filterCond{}, fmt.Errorf("empty condition")
+	}
+
+	var cond filterCond
+	if m := filterIsNullRe.FindStringSubmatch(text); m != nil {
+		cond = filterCond{column: m[1], op: "is null"}
+		if m[2] != "" {
+			cond.op = "is not null"
+		}
+	} else if m := filterCondRe.FindStringSubmatch(text); m != nil {
+		cond = filterCond{column: m[1], op: m[2], value: unquote(strings.TrimSpace(m[3]))}
+	} else {
+		// No operator: free text search across all columns
+		return filterCond{colIndex: -1, value: unquote(text)}, nil
+	}
+
+	cond.colIndex = -1
+	for i, col := range columns {
+		if strings.EqualFold(col, cond.column) {
+			cond.colIndex = i
+			cond.column = col
+			break
+		}
+	}
+	if cond.colIndex == -1 {
+		return cond, fmt.Errorf("unknown column %q", cond.column)
+	}
+
+	if cond.op == "~" {
+		re, err := regexp.Compile("(?i)" + cond.value)
+		if err != nil {
+			return cond, fmt.Errorf("invalid regex: %v", err)
+		}
+		cond.re = re
+	}
+	return cond, nil
+}
+
+// splitKeyword splits text on a whitespace-delimited keyword (case-insensitive),
+// ignoring occurrences inside quotes
+func splitKeyword(text, keyword string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	kw := len(keyword)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		if i > 0 && i+kw < len(text) && isSpace(text[i-1]) && isSpace(text[i+kw]) &&
+			strings.EqualFold(text[i:i+kw], keyword) {
+			parts = append(parts, text[start:i])
+			start = i + kw
+			i += kw - 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// matches reports whether row satisfies the expression
+func (e *filterExpr) matches(row []string) bool {
+	for _, group := range e.groups {
+		all := true
+		for _, cond := range group {
+			if !cond.matches(row) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+func (c filterCond) matches(row []string) bool {
+	if c.column == "" {
+		q := strings.ToLower(c.value)
+		for _, col := range row {
+			if strings.Contains(strings.ToLower(col), q) {
+				return true
+			}
+		}
+		return false
+	}
+	if c.colIndex >= len(row) {
+		return false
+	}
+
+	val := row[c.colIndex]
+	switch c.op {
+	case "is null":
+		return val == "NULL"
+	case "is not null":
+		return val != "NULL"
+	case "~":
+		return val != "NULL" && c.re.MatchString(val)
+	}
+
+	if val == "NULL" {
+		return false
+	}
+	cmp := compareValues(val, c.value)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareValues compares numerically or chronologically when both values
+// allow it, falling back to a case-insensitive string comparison
+func compareValues(a, b string) int {
+	if fa, err := strconv.ParseFloat(a, 64); err == nil {
+		if fb, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	}
+	if ta, ok := parseDate(a); ok {
+		if tb, ok := parseDate(b); ok {
+			return ta.Compare(tb)
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func parseDate(s string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// whereClause renders the expression as a SQL condition (without the WHERE
+// keyword) using quoted identifiers and bound parameters
+func (e *filterExpr) whereClause(columns []string, dbType string) (string, []any, error) {
+	var args []any
+	bind := func(v string) string {
+		args = append(args, v)
+		return db.Placeholder(len(args), dbType)
+	}
+
+	var orParts []string
+	for _, group := range e.groups {
+		var andParts []string
+		for _, cond := range group {
+			sql, err := cond.sql(columns, dbType, bind)
+			if err != nil {
+				return "", nil, err
+			}
+			andParts = append(andParts, sql)
+		}
+		orParts = append(orParts, "("+strings.Join(andParts, " AND ")+")")
+	}
+	return strings.Join(orParts, " OR "), args, nil
+}
+
+func (c filterCond) sql(columns []string, dbType string, bind func(string) string) (string, error) {
+	if c.column == "" {
+		// Free text: match the pattern against every column cast to text
+		like := "LIKE"
+		if dbType == "postgres" {
+			like = "ILIKE"
+		}
+		var parts []string
+		for _, col := range columns {
+			parts = append(parts, fmt.Sprintf("%s %s %s ESCAPE '!'",
+				castText(db.QuoteIdent(col, dbType), dbType), like, bind("%"+likeEscaper.Replace(c.value)+"%")))
+		}
+		return "(" + strings.Join(parts, " OR ") + ")", nil
+	}
+
+	col := db.QuoteIdent(c.column, dbType)
+	switch c.op {
+	case "is null":
+		return col + " IS NULL", nil
+	case "is not null":
+		return col + " IS NOT NULL", nil
+	case "~":
+		switch dbType {
+		case "postgres":
+			return col + " ~* " + bind(c.value), nil
+		case "mysql":
+			return col + " REGEXP " + bind(c.value), nil
+		}
+		return "", fmt.Errorf("regex filters are not supported by %s", dbType)
+	}
+
+	op := c.op
+	if op == "!=" {
+		op = "<>"
+	}
+	if !isNumberOrDate(c.value) {
+		// Text compares case-insensitively, as in matches, whatever the
+		// column's collation
+		return fmt.Sprintf("LOWER(%s) %s LOWER(%s)", castText(col, dbType), op, bind(c.value)), nil
+	}
+	return col + " " + op + " " + bind(c.value), nil
+}
+
+// castText casts a column to the engine's text type
+func castText(col, dbType string) string {
+	castType := map[string]string{"postgres": "TEXT", "sqlserver": "NVARCHAR(MAX)"}[dbType]
+	if castType == "" {
+		castType = "CHAR"
+	}
+	return fmt.Sprintf("CAST(%s AS %s)", col, castType)
+}
+
+// isNumberOrDate reports whether compareValues would compare v numerically
+// or chronologically rather than as text
+func isNumberOrDate(v string) bool {
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return true
+	}
+	_, ok := parseDate(v)
+	return ok
+}
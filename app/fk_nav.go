@@ -0,0 +1,211 @@
+// fk_nav.go handles navigating foreign key relationships from a record.
+// It follows FK values forward to the referenced parent row and lists child
+// rows that reference the current record. Each jump is recorded in the
+// navigation history (see navigation.go).
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// childRef is one FK constraint referencing the current table. Composite
+// keys have one column pair per key column, in key order.
+type childRef struct {
+	table      string
+	columns    []string // In the referencing (child) table
+	referenced []string // In the current table
+}
+
+func (r childRef) String() string {
+	if len(r.columns) == 1 {
+		return fmt.Sprintf("%s.%s -> %s", r.table, r.columns[0], r.referenced[0])
+	}
+	return fmt.Sprintf("%s (%s) -> (%s)", r.table, strings.Join(r.columns, ", "), strings.Join(r.referenced, ", "))
+}
+
+// groupChildRefs collects the rows of each referencing constraint into one
+// childRef, in the order the constraints first appear
+func groupChildRefs(deps []db.FKDependency) []childRef {
+	var refs []childRef
+	index := make(map[string]int)
+	for _, dep := range deps {
+		key := dep.TableName + "\x00" + dep.ConstraintName
+		i, ok := index[key]
+		if !ok {
+			i = len(refs)
+			index[key] = i
+			refs = append(refs, childRef{table: dep.TableName})
+		}
+		refs[i].columns = append(refs[i].columns, dep.ColumnName)
+		refs[i].referenced = append(refs[i].referenced, dep.ReferencedColumn)
+	}
+	return refs
+}
+
+// fkRefForColumn returns the outgoing FK defined on col, if any
+func (a *App) fkRefForColumn(col string) *db.FKReference {
+	for i, ref := range a.queryFKRefs {
+		if ref.ColumnName == col {
+			return &a.queryFKRefs[i]
+		}
+	}
+	return nil
+}
+
+// fkRefsForColumn returns the column pairs of the outgoing FK constraint
+// that includes col, or nil when col is not part of one
+func (a *App) fkRefsForColumn(col string) []db.FKReference {
+	ref := a.fkRefForColumn(col)
+	if ref == nil {
+		return nil
+	}
+	var refs []db.FKReference
+	for _, r := range a.queryFKRefs {
+		if r.ConstraintName == ref.ConstraintName && r.ReferencedTable == ref.ReferencedTable {
+			refs = append(refs, r)
+		}
+	}
+	return refs
+}
+
+// keyWhereClause matches each of columns to the value of the matching
+// valueColumns entry in the selected row. A NULL value matches nothing, so
+// it is reported instead.
+func (a *App) keyWhereClause(columns, valueColumns []string) (string, error) {
+	row := a.filteredResultRows[a.resultCursor]
+	var parts []string
+	for i, col := range columns {
+		idx := a.columnIndex(valueColumns[i])
+		if idx == -1 || idx >= len(row) {
+			return "", fmt.Errorf("column %s not in result", valueColumns[i])
+		}
+		if row[idx] == "NULL" {
+			return "", fmt.Errorf("%s is NULL", valueColumns[i])
+		}
+		parts = append(parts, db.QuoteIdent(col, a.dbType)+" = "+db.SQLLiteral(row[idx]))
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+func (a *App) columnIndex(col string) int {
+	if a.queryResult == nil {
+		return -1
+	}
+	for i, c := range a.queryResult.Columns {
+		if c == col {
+			return i
+		}
+	}
+	return -1
+}
+
+// followFK jumps from the FK value under the field cursor to the parent row,
+// matching every column of a composite key
+func (a *App) followFK() error {
+	if a.queryResult == nil || len(a.filteredResultRows) == 0 {
+		return fmt.Errorf("no record selected")
+	}
+	if a.fieldCursor >= len(a.queryResult.Columns) {
+		return nil
+	}
+	col := a.queryResult.Columns[a.fieldCursor]
+	refs := a.fkRefsForColumn(col)
+	if refs == nil {
+		return fmt.Errorf("%s is not a foreign key", col)
+	}
+	var columns, referenced []string
+	for _, ref := range refs {
+		columns = append(columns, ref.ColumnName)
+		referenced = append(referenced, ref.ReferencedColumn)
+	}
+	where, err := a.keyWhereClause(referenced, columns)
+	if err != nil {
+		return err
+	}
+
+	parent := refs[0].ReferencedTable
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", db.FormatTableName(parent, a.dbType), where)
+	a.executeQuery(query, parent)
+	return nil
+}
+
+// startChildRefs lists the tables whose FKs reference the current table. A
+// single match is followed immediately; otherwise a chooser is shown.
+func (a *App) startChildRefs() error {
+	if a.queryResult == nil || len(a.filteredResultRows) == 0 {
+		return fmt.Errorf("no record selected")
+	}
+	if a.queryTableName == "" {
+		return fmt.Errorf("could not determine table name from query")
+	}
+	deps, err := db.GetReferencingFKs(a.db, a.selectedDatabase, a.queryTableName, a.dbType)
+	if err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		return fmt.Errorf("no tables reference %s", a.queryTableName)
+	}
+	refs := groupChildRefs(deps)
+	if len(refs) == 1 {
+		return a.followChildRef(refs[0])
+	}
+	a.childRefs = refs
+	a.childRefCursor = 0
+	a.showingChildRefs = true
+	return nil
+}
+
+// followChildRef queries the child rows that reference the current record
+func (a *App) followChildRef(ref childRef) error {
+	where, err := a.keyWhereClause(ref.columns, ref.referenced)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", db.FormatTableName(ref.table, a.dbType), where)
+	a.executeQuery(query, ref.table)
+	return nil
+}
+
+func (a *App) updateChildRefs(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		a.showingChildRefs = false
+		a.childRefs = nil
+	case "j", "down":
+		a.childRefCursor = moveCursor(a.childRefCursor, 1, len(a.childRefs))
+	case "k", "up":
+		a.childRefCursor = moveCursor(a.childRefCursor, -1, len(a.childRefs))
+	case "enter":
+		ref := a.childRefs[a.childRefCursor]
+		a.showingChildRefs = false
+		a.childRefs = nil
+		if err := a.followChildRef(ref); err != nil {
+			a.queryErr = err
+		}
+	}
+	return a, nil
+}
+
+func (a *App) viewChildRefs() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Rows referencing " + a.queryTableName))
+	b.WriteString("\n\n")
+
+	for i, ref := range a.childRefs {
+		line := ref.String()
+		if i == a.childRefCursor {
+			b.WriteString(editingStyle.Render("> " + line))
+		} else {
+			b.WriteString(dimStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+
+	controls := "j/k: navigate • enter: show rows • esc: cancel"
+	return a.renderFrame(b.String(), controls)
+}
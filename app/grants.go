@@ -0,0 +1,142 @@
+// grants.go shows a read-only overlay of the connection's users and roles,
+// their role memberships and the privileges granted on the selected table,
+// headed by a summary of what the connected user may do on it. The same
+// summary is shown on the edit and delete confirmations.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	grantsTabPrivileges = iota
+	grantsTabRoles
+)
+
+var grantsTabNames = []string{"Table privileges", "Users & roles"}
+
+func (a *App) startGrants(tableName string) {
+	a.grantsTable = tableName
+	a.grantsTab = grantsTabPrivileges
+	a.grantsScroll = 0
+	a.grantsAccess, a.grantsAccessErr = db.GetTableAccess(a.db, a.selectedDatabase, tableName, a.dbType)
+	a.grantsPrivileges, a.grantsPrivilegesErr = db.GetTablePrivileges(a.db, a.selectedDatabase, tableName, a.dbType)
+	a.grantsRoles, a.grantsRolesErr = db.ListRoles(a.db, a.selectedDatabase, a.dbType)
+	a.showingGrants = true
+}
+
+// loadConfirmAccess looks up the user's access to the table being edited so
+// the confirmation can warn before a statement that will be refused. Lookup
+// failures just leave the summary out.
+func (a *App) loadConfirmAccess() {
+	a.confirmAccess, _ = db.GetTableAccess(a.db, a.selectedDatabase, a.queryTableName, a.dbType)
+}
+
+// viewConfirmAccess renders the access summary for a confirmation, warning
+// when the user lacks priv
+func (a *App) viewConfirmAccess(priv string) string {
+	if a.confirmAccess == nil {
+		return ""
+	}
+	s := dimStyle.Render(a.confirmAccess.Summary()) + "\n"
+	for _, missing := range a.confirmAccess.Missing() {
+		if missing == priv {
+			s += errorStyle.Render(fmt.Sprintf("Warning: %s on %s is not granted; the statement will likely fail",
+				priv, a.queryTableName)) + "\n"
+		}
+	}
+	return s + "\n"
+}
+
+func (a *App) updateGrants(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.showingGrants = false
+	case "tab", "shift+tab":
+		a.grantsTab = (a.grantsTab + 1) % len(grantsTabNames)
+		a.grantsScroll = 0
+	case "j", "down":
+		a.grantsScroll++
+	case "k", "up":
+		a.grantsScroll = max(0, a.grantsScroll-1)
+	}
+	return a, nil
+}
+
+func (a *App) grantsLines() []string {
+	var lines []string
+	switch a.grantsTab {
+	case grantsTabPrivileges:
+		if a.grantsPrivilegesErr != nil {
+			return []string{errorStyle.Render("Error: " + a.grantsPrivilegesErr.Error())}
+		}
+		if len(a.grantsPrivileges) == 0 {
+			return []string{dimStyle.Render("No privileges granted on this table")}
+		}
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%-24s %-12s %-9s", "Grantee", "Privilege", "Scope")))
+		for _, p := range a.grantsPrivileges {
+			line := fmt.Sprintf("%-24s %-12s %-9s", p.Grantee, p.Privilege, p.Scope)
+			switch {
+			case p.Denied:
+				line = errorStyle.Render(line + " DENY")
+			case p.Grantable:
+				line += dimStyle.Render(" with grant option")
+			}
+			lines = append(lines, line)
+		}
+	case grantsTabRoles:
+		if a.grantsRolesErr != nil {
+			return []string{errorStyle.Render("Error: " + a.grantsRolesErr.Error())}
+		}
+		if len(a.grantsRoles) == 0 {
+			return []string{dimStyle.Render("No users or roles visible")}
+		}
+		for _, r := range a.grantsRoles {
+			line := fmt.Sprintf("%-24s %-6s", r.Name, r.Kind)
+			if r.Superuser {
+				line += editingStyle.Render(" superuser")
+			}
+			if len(r.MemberOf) > 0 {
+				line += dimStyle.Render(" member of " + strings.Join(r.MemberOf, ", "))
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (a *App) viewGrants() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Grants: " + a.grantsTable))
+	b.WriteString("\n\n")
+
+	switch {
+	case a.grantsAccessErr != nil:
+		b.WriteString(errorStyle.Render("Could not check your access: " + a.grantsAccessErr.Error()))
+	case a.grantsAccess != nil:
+		b.WriteString(valueStyle.Render("You: " + a.grantsAccess.Summary()))
+	}
+	b.WriteString("\n\n")
+
+	for i, name := range grantsTabNames {
+		if i == a.grantsTab {
+			b.WriteString(activeTabStyle.Render(name))
+		} else {
+			b.WriteString(inactiveTabStyle.Render(name))
+		}
+		b.WriteString(" ")
+	}
+	b.WriteString("\n\n")
+
+	lines := a.grantsLines()
+	height := max(1, a.viewport.Height-6)
+	a.grantsScroll = min(a.grantsScroll, max(0, len(lines)-height))
+	end := min(len(lines), a.grantsScroll+height)
+	b.WriteString(strings.Join(lines[a.grantsScroll:end], "\n"))
+
+	return a.renderFrame(b.String(), "tab: switch view • j/k: scroll • esc: close")
+}
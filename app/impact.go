@@ -0,0 +1,134 @@
+// impact.go previews how many rows an inline edit or delete will touch by
+// running a COUNT with the statement's WHERE clause before confirmation.
+// Above the configured threshold the confirmation must be typed (the table
+// name or the row count), and the statement is rolled back if it affects a
+// different number of rows than was shown.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prepareImpact counts the rows of tableName matching where and decides
+// whether the confirmation has to be typed
+func (a *App) prepareImpact(tableName, where string) {
+	a.impactCount, a.impactErr = a.countMatching(tableName, where)
+	a.impactTyping = a.impactErr == nil && a.impactCount >= int64(a.config.TypedConfirmThreshold())
+	a.impactInput.Reset()
+	if a.impactTyping {
+		a.impactInput.Focus()
+	} else {
+		a.impactInput.Blur()
+	}
+}
+
+func (a *App) countMatching(tableName, where string) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", db.FormatTableName(tableName, a.dbType), where)
+	result, err := db.RunQuery(a.db, db.PrependUseDatabase(query, a.selectedDatabase, a.dbType))
+	if err != nil {
+		return 0, fmt.Errorf("could not count affected rows: %w", err)
+	}
+	if len(result.Rows) == 0 || len(result.Rows[0]) == 0 {
+		return 0, fmt.Errorf("could not count affected rows")
+	}
+	return strconv.ParseInt(result.Rows[0][0], 10, 64)
+}
+
+func (a *App) clearImpact() {
+	a.impactCount = 0
+	a.impactErr = nil
+	a.impactTyping = false
+	a.impactInput.Reset()
+	a.impactInput.Blur()
+}
+
+// impactTypedMatches reports whether the typed confirmation names the table
+// or the exact row count
+func (a *App) impactTypedMatches() bool {
+	typed := strings.TrimSpace(a.impactInput.Value())
+	if typed == "" {
+		return false
+	}
+	_, table := db.SplitSchemaTable(a.queryTableName, a.dbType)
+	return typed == strconv.FormatInt(a.impactCount, 10) ||
+		strings.EqualFold(typed, a.queryTableName) ||
+		strings.EqualFold(typed, table)
+}
+
+// impactKey translates a key pressed on an edit or delete confirmation.
+// While a typed confirmation is required, keys go to the input and entering
+// a match confirms as "y". "y" is ignored when the rows could not be counted.
+func (a *App) impactKey(msg tea.KeyMsg) (string, tea.Cmd) {
+	key := msg.String()
+	switch {
+	case a.impactTyping:
+		switch key {
+		case "esc", "up", "down":
+			return key, nil
+		case "enter":
+			if a.impactTypedMatches() {
+				return "y", nil
+			}
+			return "", nil
+		}
+		var cmd tea.Cmd
+		a.impactInput, cmd = a.impactInput.Update(msg)
+		return "", cmd
+	case a.impactErr != nil && (key == "y" || key == "Y"):
+		return "", nil
+	}
+	return key, nil
+}
+
+// execConfirmed runs a confirmed edit or delete, refusing it if it affects a
+// different number of rows than the previewed count. logErr is set when the
+// statement ran but could not be logged.
+func (a *App) execConfirmed(query string) (logErr, err error) {
+	rows, err := db.ExecExpecting(a.db, a.selectedDatabase, query, a.dbType, a.impactCount)
+	if err != nil {
+		// The statement was rolled back
+		rows = 0
+	}
+	return a.audit(query, rows, err), err
+}
+
+// viewImpact renders the previewed row count and, above the threshold, the
+// typed confirmation prompt in place of y/n
+func (a *App) viewImpact(verb string) string {
+	var b strings.Builder
+	if a.impactErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.impactErr.Error()))
+		b.WriteString("\n\n")
+		return b.String()
+	}
+
+	rows := "rows"
+	if a.impactCount == 1 {
+		rows = "row"
+	}
+	b.WriteString(editingStyle.Render(fmt.Sprintf("This %s will affect %d %s", verb, a.impactCount, rows)))
+	b.WriteString("\n\n")
+	if a.impactTyping {
+		_, table := db.SplitSchemaTable(a.queryTableName, a.dbType)
+		b.WriteString(fmt.Sprintf("Type the table name (%s) or the row count to confirm:\n", table))
+		b.WriteString(inputLabelStyle.Render("> ") + a.impactInput.View())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// impactControls returns the confirmation key help
+func (a *App) impactControls() string {
+	switch {
+	case a.impactErr != nil:
+		return "esc: cancel"
+	case a.impactTyping:
+		return "enter: confirm • esc: cancel"
+	}
+	return "y: execute • n/esc: cancel"
+}
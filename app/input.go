@@ -47,11 +47,16 @@ func (a *App) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.inputErr = nil
 			return a, tea.Batch(a.testConnection(connString), a.inputSpinner.Tick)
 		}
-		// Step 1: save connection
-		a.config.AddConnection(
+		// Step 1: save connection. The template is saved unexpanded, but
+		// the type comes from what it expands to
+		dbType := db.DetectDBType(a.connInput.Value())
+		if expanded, err := db.ExpandConnString(a.connInput.Value()); err == nil {
+			dbType = db.DetectDBType(expanded)
+		}
+		a.listErr = a.config.AddConnection(
 			a.nameInput.Value(),
 			a.connInput.Value(),
-			db.DetectDBType(a.connInput.Value()),
+			dbType,
 		)
 		a.config.Save()
 		a.mode = modeList
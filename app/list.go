@@ -3,7 +3,6 @@
 package app
 
 import (
-	"dbsurf/db"
 	"fmt"
 	"strings"
 
@@ -12,6 +11,11 @@ import (
 )
 
 func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.showingAudit {
+		return a.updateAuditLog(msg)
+	}
+
+	a.listErr = nil
 	switch msg.String() {
 	case "j", "down":
 		a.cursor = moveCursor(a.cursor, 1, len(a.config.Connections))
@@ -22,8 +26,33 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.inputStep = 0
 		a.connInput.Focus()
 		return a, textinput.Blink
+	case "ctrl+s":
+		if len(a.sessions) > 0 {
+			a.startSessionSwitcher()
+		}
+	case "L":
+		a.startAuditLog()
+	case "s":
+		if len(a.config.Connections) > 0 {
+			conn := a.config.Connections[a.cursor]
+			a.config.CycleSafety(conn)
+			a.config.Save()
+			a.syncSessionConn(a.config.Connections[a.cursor])
+		}
+	case "p":
+		if len(a.config.Connections) > 0 {
+			conn := a.config.Connections[a.cursor]
+			a.config.ToggleProduction(conn)
+			a.config.Save()
+			a.syncSessionConn(a.config.Connections[a.cursor])
+		}
 	case "d":
 		if len(a.config.Connections) > 0 {
+			if i := a.findSession(a.config.Connections[a.cursor]); i != -1 {
+				a.closeSession(i)
+				a.mode = modeList
+			}
+			a.listErr = a.config.DeleteSecret(a.config.Connections[a.cursor])
 			a.config.Connections = append(
 				a.config.Connections[:a.cursor],
 				a.config.Connections[a.cursor+1:]...,
@@ -35,31 +64,7 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "enter":
 		if len(a.config.Connections) > 0 {
-			if a.db != nil {
-				a.db.Close()
-				a.db = nil
-			}
-			conn := a.config.Connections[a.cursor]
-			a.dbType = conn.DBType
-			a.db, a.dbErr = db.Connect(conn.ConnString)
-			if a.dbErr == nil {
-				if a.dbType == "postgres" {
-					a.selectedDatabase = ""
-					a.queryInput.Reset()
-					a.queryInput.Focus()
-					a.queryFocused = true
-					a.queryResult = nil
-					a.queryErr = nil
-					a.mode = modeQuery
-					return a, textinput.Blink
-				}
-				a.databases, _ = db.ListDatabases(a.db, a.dbType)
-			}
-			a.filteredDatabases = a.databases
-			a.dbCursor = 0
-			a.dbSearching = false
-			a.dbSearchInput.Reset()
-			a.mode = modeConnected
+			return a, a.openSession(a.config.Connections[a.cursor])
 		}
 	}
 	return a, nil
@@ -68,8 +73,16 @@ func (a *App) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) viewList() string {
 	var content string
 
+	if a.showingAudit {
+		return a.viewAuditLog()
+	}
+
+	if a.listErr != nil {
+		content = errorStyle.Render("Error: "+a.listErr.Error()) + "\n\n"
+	}
+
 	if len(a.config.Connections) == 0 {
-		content = dimStyle.Render("No saved connections")
+		content += dimStyle.Render("No saved connections")
 	} else {
 		var lines []string
 		for i, conn := range a.config.Connections {
@@ -79,14 +92,20 @@ func (a *App) viewList() string {
 				prefix = "> "
 				line = selectedStyle.Render(line)
 			}
+			if s := a.findSession(conn); s != -1 {
+				line += " " + a.sessions[s].style().Render("●")
+			}
+			if badges := safetyBadges(conn); badges != "" {
+				line += " " + badges
+			}
 			lines = append(lines, prefix+line)
 		}
 		a.viewport.SetContent(strings.Join(lines, "\n"))
 		a.syncViewportToCursor(a.cursor, len(a.config.Connections))
-		content = a.viewport.View()
+		content += a.viewport.View()
 	}
 
-	controls := "j/k: navigate • n: new • d: delete • q: quit"
+	controls := "j/k: navigate • n: new • d: delete • s: safety level • p: production • L: audit log • ctrl+s: open connections • q: quit"
 
 	return a.renderFrame(content, controls)
 }
@@ -5,10 +5,12 @@ package app
 
 import (
 	"database/sql"
+	"dbsurf/audit"
 	"dbsurf/config"
 	"dbsurf/db"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
@@ -79,6 +81,7 @@ const (
 	modeConnected
 	modeQuery
 	modeTableList
+	modeActivity
 )
 
 type App struct {
@@ -101,6 +104,15 @@ type App struct {
 	inputErr     error
 	inputTesting bool
 	inputSpinner spinner.Model
+	listErr      error
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Open Sessions
+	// ─────────────────────────────────────────────────────────────────────────
+	sessions        []session
+	activeSession   int
+	showingSessions bool
+	sessionCursor   int
 
 	// ─────────────────────────────────────────────────────────────────────────
 	// Database Connection
@@ -113,17 +125,20 @@ type App struct {
 	dbCursor          int
 	dbSearching       bool
 	dbSearchInput     textinput.Model
+	dbSwitchErr       error // Failure reconnecting to another Postgres database
 
 	// ─────────────────────────────────────────────────────────────────────────
 	// Query Mode
 	// ─────────────────────────────────────────────────────────────────────────
 	selectedDatabase      string
 	queryInput            textinput.Model
+	queryArgs             []any
 	queryResult           *db.QueryResult
 	queryErr              error
 	queryFocused          bool
 	queryTableName        string
 	queryPKColumns        []string
+	queryFKRefs           []db.FKReference
 	advancedQueryTempFile string
 
 	// ─────────────────────────────────────────────────────────────────────────
@@ -133,6 +148,8 @@ type App struct {
 	resultSearching    bool
 	resultSearchInput  textinput.Model
 	resultFilter       string
+	resultFilterErr    error
+	resultSort         []sortKey
 	filteredResultRows [][]string
 	fieldCursor        int
 	copySuccess        bool
@@ -146,6 +163,23 @@ type App struct {
 	editConfirming     bool
 	pendingUpdateSQL   string
 
+	// ─────────────────────────────────────────────────────────────────────────
+	// Write Confirmation (confirm-writes connections)
+	// ─────────────────────────────────────────────────────────────────────────
+	writeConfirming     bool
+	pendingWriteSQL     string
+	pendingWriteTable   string
+	pendingWriteArgs    []any
+	pendingWriteExplain bool // Confirming EXPLAIN ANALYZE rather than running the statement
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Impact Preview (edit/delete confirmation)
+	// ─────────────────────────────────────────────────────────────────────────
+	impactCount  int64 // Rows matched by the pending statement's WHERE clause
+	impactErr    error
+	impactTyping bool // Above the threshold; confirmation must be typed
+	impactInput  textinput.Model
+
 	// ─────────────────────────────────────────────────────────────────────────
 	// Record Deletion
 	// ─────────────────────────────────────────────────────────────────────────
@@ -157,6 +191,60 @@ type App struct {
 	fkDependencyCounts map[string]int
 	fkDepCursor        int
 
+	// ─────────────────────────────────────────────────────────────────────────
+	// Result Tabs
+	// ─────────────────────────────────────────────────────────────────────────
+	tabs           []resultTab
+	activeTab      int
+	tabRenaming    bool
+	tabRenameInput textinput.Model
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Navigation History
+	// ─────────────────────────────────────────────────────────────────────────
+	navBack    []navEntry
+	navForward []navEntry
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// FK Navigation
+	// ─────────────────────────────────────────────────────────────────────────
+	showingChildRefs bool
+	childRefs        []childRef
+	childRefCursor   int
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Watch Mode
+	// ─────────────────────────────────────────────────────────────────────────
+	watching           bool
+	watchPaused        bool
+	watchPrompting     bool
+	watchIntervalInput textinput.Model
+	watchInterval      time.Duration
+	watchLastRun       time.Time
+	watchChanges       map[string]map[int]bool
+	watchSeq           int
+	watchQuery         string // query and args checked when watching started
+	watchArgs          []any
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Result Diff
+	// ─────────────────────────────────────────────────────────────────────────
+	diffSnapshot      *db.QueryResult
+	diffSnapshotPK    []string
+	diffSnapshotQuery string
+	snapshotTaken     bool
+	showingDiff       bool
+	diff              resultDiff
+	diffCursor        int
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Column Profile
+	// ─────────────────────────────────────────────────────────────────────────
+	showingProfile bool
+	profileStats   *db.ColumnStats
+	profileSource  string
+	profileErr     error
+
 	// ─────────────────────────────────────────────────────────────────────────
 	// Column Info View
 	// ─────────────────────────────────────────────────────────────────────────
@@ -167,15 +255,135 @@ type App struct {
 	columnInfoSearching   bool
 	columnInfoSearchInput textinput.Model
 	columnInfoFilter      string
+	columnInfoTab         int
+	columnInfoFKs         map[string]string // Column -> referenced table.column
+	columnInfoIndexes     []db.IndexInfo
+	columnInfoConstraints []db.ConstraintInfo
+	columnInfoMetaErr     error // Failure loading indexes or constraints
 
 	// ─────────────────────────────────────────────────────────────────────────
 	// Table List Mode
 	// ─────────────────────────────────────────────────────────────────────────
-	tables           []string
-	filteredTables   []string
+	tables           []db.DBObject
+	filteredTables   []db.DBObject
 	tableCursor      int
 	tableSearching   bool
 	tableSearchInput textinput.Model
+	tableSchemas     []string
+	tableSchema      string // Only list objects in this schema, "" for all
+	schemaListing    bool
+	schemaListCursor int
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Table Stats
+	// ─────────────────────────────────────────────────────────────────────────
+	showingTableStats bool
+	tableStats        map[string]db.TableStats // Loaded lazily, reset with the table list
+	tableSortKey      int                      // Index into tableSortKeys
+	tableStatsErr     error
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Table Compare
+	// ─────────────────────────────────────────────────────────────────────────
+	comparePicking    bool
+	comparing         bool
+	showingCompare    bool
+	compareTable      string
+	compareConnCursor int
+	compareTarget     db.TableSide
+	compareTargetName string
+	compareResult     *db.TableComparison
+	compareCursor     int
+	compareErr        error
+	compareExportPath string
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Schema Diff
+	// ─────────────────────────────────────────────────────────────────────────
+	schemaPickingConn bool
+	schemaPickingDB   bool
+	schemaDiffing     bool
+	showingSchemaDiff bool
+	schemaCursor      int
+	schemaTarget      db.TableSide
+	schemaTargetName  string
+	schemaTargetOwned bool // Temporary connection closed with the diff
+	schemaTargetDBs   []string
+	schemaDiff        *db.SchemaDiff
+	schemaDiffErr     error
+	schemaDiffScroll  int
+	schemaExportPath  string
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Table DDL
+	// ─────────────────────────────────────────────────────────────────────────
+	showingDDL bool
+	ddlTable   string
+	ddlText    string
+	ddlErr     error
+	ddlScroll  int
+	ddlStatus  string
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Query Plan
+	// ─────────────────────────────────────────────────────────────────────────
+	showingExplain   bool
+	explainQuery     string
+	explainAnalyze   bool // Plan was run with ANALYZE (Postgres only)
+	explainPlan      *db.PlanNode
+	explainErr       error
+	explainCursor    int
+	explainCollapsed map[*db.PlanNode]bool
+	explainHot       map[*db.PlanNode]int // Most expensive nodes, ranked from 1
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Grants
+	// ─────────────────────────────────────────────────────────────────────────
+	showingGrants       bool
+	grantsTable         string
+	grantsTab           int
+	grantsScroll        int
+	grantsAccess        *db.TableAccess
+	grantsAccessErr     error
+	grantsPrivileges    []db.TablePrivilege
+	grantsPrivilegesErr error
+	grantsRoles         []db.DBRole
+	grantsRolesErr      error
+	confirmAccess       *db.TableAccess // Access to the table of a pending edit or delete
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Procedure Execution
+	// ─────────────────────────────────────────────────────────────────────────
+	showingExecForm bool
+	execProcedure   string
+	execParams      []db.RoutineParam
+	execInputs      []textinput.Model // One per parameter; OUT parameters are skipped
+	execFocus       int
+	execErr         error
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Server Activity
+	// ─────────────────────────────────────────────────────────────────────────
+	activitySessions []db.ServerSession
+	activityErr      error
+	activityCursor   int
+	activityShowIdle bool
+	activityLockTree bool
+	activityPaused   bool
+	activitySeq      int
+	activityLastRun  time.Time
+	activityConfirm  string // "cancel" or "kill" while awaiting confirmation
+	activityTarget   db.ServerSession
+	activityStatus   string
+	activityCmdErr   error // Failed cancel/kill, kept across refreshes
+
+	// ─────────────────────────────────────────────────────────────────────────
+	// Audit Log
+	// ─────────────────────────────────────────────────────────────────────────
+	showingAudit bool
+	auditEntries []audit.Entry // Newest first
+	auditErr     error
+	auditCursor  int
 
 	// ─────────────────────────────────────────────────────────────────────────
 	// UI Components
@@ -208,8 +416,8 @@ func New() *App {
 	qi.Width = 60
 
 	ri := textinput.New()
-	ri.Placeholder = "Filter results..."
-	ri.Width = 30
+	ri.Placeholder = "text or col=value AND col>10..."
+	ri.Width = 40
 
 	ti := textinput.New()
 	ti.Placeholder = "Filter tables..."
@@ -223,6 +431,18 @@ func New() *App {
 	cfi.Placeholder = "Filter columns..."
 	cfi.Width = 30
 
+	tri := textinput.New()
+	tri.Placeholder = "Tab name"
+	tri.Width = 16
+
+	ii := textinput.New()
+	ii.Placeholder = "table name or row count"
+	ii.Width = 30
+
+	wi := textinput.New()
+	wi.Placeholder = "5"
+	wi.Width = 6
+
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(ColorPrimary)
@@ -232,9 +452,17 @@ func New() *App {
 	vp.KeyMap = viewport.KeyMap{}
 	vp.MouseWheelEnabled = true
 
+	// Passwords the secret backend could not take are still in config.json
+	var listErr error
+	if cfg != nil {
+		listErr = cfg.SecretErr
+	}
+
 	return &App{
 		config:                cfg,
+		listErr:               listErr,
 		mode:                  modeList,
+		activeSession:         -1,
 		connInput:             ci,
 		nameInput:             ni,
 		inputSpinner:          sp,
@@ -245,6 +473,9 @@ func New() *App {
 		tableSearchInput:      ti,
 		fieldEditInput:        fi,
 		columnInfoSearchInput: cfi,
+		tabRenameInput:        tri,
+		watchIntervalInput:    wi,
+		impactInput:           ii,
 		viewport:              vp,
 	}
 }
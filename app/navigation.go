@@ -0,0 +1,131 @@
+// navigation.go keeps a browser-style history of result states. Every query,
+// table selection and FK jump pushes the previous result so the user can move
+// back and forward through what they explored, with a breadcrumb showing the
+// path taken.
+package app
+
+import (
+	"dbsurf/db"
+	"strings"
+)
+
+const (
+	maxNavHistory    = 50
+	maxBreadcrumbLen = 4
+)
+
+// navEntry captures the result state needed to return to an earlier query.
+type navEntry struct {
+	query        string
+	args         []any
+	result       *db.QueryResult
+	filter       string
+	sort         []sortKey
+	resultCursor int
+	fieldCursor  int
+	tableName    string
+	pkColumns    []string
+	fkRefs       []db.FKReference
+}
+
+func (e navEntry) label() string {
+	if e.tableName != "" {
+		return e.tableName
+	}
+	return truncate(e.query, 20)
+}
+
+func (a *App) currentNavEntry() navEntry {
+	return navEntry{
+		query:        a.queryInput.Value(),
+		args:         a.queryArgs,
+		result:       a.queryResult,
+		filter:       a.resultFilter,
+		sort:         a.resultSort,
+		resultCursor: a.resultCursor,
+		fieldCursor:  a.fieldCursor,
+		tableName:    a.queryTableName,
+		pkColumns:    a.queryPKColumns,
+		fkRefs:       a.queryFKRefs,
+	}
+}
+
+func (a *App) restoreNavEntry(e navEntry) {
+	a.stopWatch()
+	a.queryInput.SetValue(e.query)
+	a.queryArgs = e.args
+	a.queryResult = e.result
+	a.queryErr = nil
+	a.resultFilter = e.filter
+	a.resultSort = e.sort
+	a.resultSearchInput.SetValue(e.filter)
+	a.filterResults()
+	a.resultCursor = min(e.resultCursor, max(0, len(a.filteredResultRows)-1))
+	a.fieldCursor = e.fieldCursor
+	a.queryTableName = e.tableName
+	a.queryPKColumns = e.pkColumns
+	a.queryFKRefs = e.fkRefs
+}
+
+// pushNav records the current result before navigating somewhere new. Any
+// forward history is discarded, as in a web browser.
+func (a *App) pushNav() {
+	if a.queryResult == nil {
+		return
+	}
+	a.navBack = append(a.navBack, a.currentNavEntry())
+	if len(a.navBack) > maxNavHistory {
+		a.navBack = a.navBack[len(a.navBack)-maxNavHistory:]
+	}
+	a.navForward = nil
+}
+
+// navigateBack restores the previous result, keeping the current one for forward
+func (a *App) navigateBack() {
+	if len(a.navBack) == 0 {
+		return
+	}
+	entry := a.navBack[len(a.navBack)-1]
+	a.navBack = a.navBack[:len(a.navBack)-1]
+	if a.queryResult != nil {
+		a.navForward = append(a.navForward, a.currentNavEntry())
+	}
+	a.restoreNavEntry(entry)
+}
+
+// navigateForward re-opens a result that was left via navigateBack
+func (a *App) navigateForward() {
+	if len(a.navForward) == 0 {
+		return
+	}
+	entry := a.navForward[len(a.navForward)-1]
+	a.navForward = a.navForward[:len(a.navForward)-1]
+	if a.queryResult != nil {
+		a.navBack = append(a.navBack, a.currentNavEntry())
+	}
+	a.restoreNavEntry(entry)
+}
+
+// resetNav clears the history, e.g. when switching databases
+func (a *App) resetNav() {
+	a.navBack = nil
+	a.navForward = nil
+}
+
+// breadcrumb renders the most recent back entries followed by the current result
+func (a *App) breadcrumb() string {
+	if len(a.navBack) == 0 {
+		return ""
+	}
+	var parts []string
+	start := 0
+	if len(a.navBack) > maxBreadcrumbLen {
+		start = len(a.navBack) - maxBreadcrumbLen
+		parts = append(parts, "…")
+	}
+	for _, e := range a.navBack[start:] {
+		parts = append(parts, e.label())
+	}
+	parts = append(parts, a.currentNavEntry().label())
+	return strings.Join(parts, " > ")
+}
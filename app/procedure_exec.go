@@ -0,0 +1,122 @@
+// procedure_exec.go implements the "execute with parameters" form for stored
+// procedures. Each input parameter gets a text field; the call is run as a
+// parameterized statement and its result shown in query mode.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (a *App) startProcedureExec(name string) tea.Cmd {
+	params, err := db.GetRoutineParams(a.db, a.selectedDatabase, name, a.dbType)
+	a.execProcedure = name
+	a.execParams = params
+	a.execErr = err
+	a.execInputs = make([]textinput.Model, len(params))
+	for i, p := range params {
+		ti := textinput.New()
+		ti.Placeholder = p.DataType
+		ti.Width = 30
+		a.execInputs[i] = ti
+	}
+	a.execFocus = -1
+	a.moveExecFocus(1)
+	a.showingExecForm = true
+	return textinput.Blink
+}
+
+// moveExecFocus moves focus to the next input parameter in direction delta,
+// skipping OUT parameters
+func (a *App) moveExecFocus(delta int) {
+	for i := a.execFocus + delta; i >= 0 && i < len(a.execParams); i += delta {
+		if a.execParams[i].IsInput() {
+			if a.execFocus >= 0 {
+				a.execInputs[a.execFocus].Blur()
+			}
+			a.execFocus = i
+			a.execInputs[i].Focus()
+			return
+		}
+	}
+}
+
+// procedureArgs returns the bind args for the input parameters. "NULL" binds
+// a SQL NULL.
+func (a *App) procedureArgs() []any {
+	var args []any
+	for i, p := range a.execParams {
+		if !p.IsInput() {
+			continue
+		}
+		if v := a.execInputs[i].Value(); v == "NULL" {
+			args = append(args, nil)
+		} else {
+			args = append(args, v)
+		}
+	}
+	return args
+}
+
+func (a *App) updateProcedureExec(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.showingExecForm = false
+		return a, nil
+	case "tab", "down":
+		a.moveExecFocus(1)
+		return a, nil
+	case "shift+tab", "up":
+		a.moveExecFocus(-1)
+		return a, nil
+	case "enter":
+		if a.execErr != nil {
+			return a, nil
+		}
+		call := db.BuildProcedureCall(a.execProcedure, a.execParams, a.dbType)
+		a.showingExecForm = false
+		a.runStatement(call, "", a.procedureArgs()...)
+		a.mode = modeQuery
+		return a, nil
+	}
+
+	if a.execFocus < 0 {
+		return a, nil
+	}
+	var cmd tea.Cmd
+	a.execInputs[a.execFocus], cmd = a.execInputs[a.execFocus].Update(msg)
+	return a, cmd
+}
+
+func (a *App) viewProcedureExec() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Execute " + a.execProcedure))
+	b.WriteString("\n\n")
+
+	if a.execErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.execErr.Error()))
+		return a.renderFrame(b.String(), "esc: cancel")
+	}
+
+	if len(a.execParams) == 0 {
+		b.WriteString(dimStyle.Render("No parameters"))
+		b.WriteString("\n")
+	}
+	for i, p := range a.execParams {
+		label := inputLabelStyle.Render(fmt.Sprintf("%s (%s %s): ", p.Name, p.Mode, p.DataType))
+		if !p.IsInput() {
+			b.WriteString(label + dimStyle.Render("output"))
+		} else {
+			b.WriteString(label + a.execInputs[i].View())
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(db.BuildProcedureCall(a.execProcedure, a.execParams, a.dbType)))
+
+	return a.renderFrame(b.String(), "tab/↑/↓: next field • enter: execute • NULL: null value • esc: cancel")
+}
@@ -7,6 +7,7 @@ import (
 	"dbsurf/db"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,46 +41,32 @@ func hasJoin(query string) bool {
 }
 
 func (a *App) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.showingExplain {
+		return a.updateExplain(msg)
+	}
+
+	if a.showingDDL {
+		return a.updateTableDDL(msg)
+	}
+
 	if a.showingColumnInfo {
-		if a.columnInfoSearching {
-			switch msg.String() {
-			case "esc":
-				a.columnInfoSearching = false
-				a.columnInfoSearchInput.Blur()
-				return a, nil
-			case "enter":
-				a.columnInfoSearching = false
-				a.columnInfoSearchInput.Blur()
-				return a, nil
-			}
-			var cmd tea.Cmd
-			a.columnInfoSearchInput, cmd = a.columnInfoSearchInput.Update(msg)
-			a.columnInfoFilter = a.columnInfoSearchInput.Value()
-			a.filterAndRebuildColumnInfo()
-			return a, cmd
-		}
+		return a.updateColumnInfo(msg)
+	}
 
-		switch msg.String() {
-		case "esc":
-			if a.columnInfoFilter != "" {
-				a.columnInfoFilter = ""
-				a.columnInfoSearchInput.SetValue("")
-				a.filterAndRebuildColumnInfo()
-				return a, nil
-			}
-			a.showingColumnInfo = false
-			return a, nil
-		case "?", "q":
-			a.showingColumnInfo = false
-			return a, nil
-		case "/":
-			a.columnInfoSearching = true
-			a.columnInfoSearchInput.Focus()
-			return a, textinput.Blink
-		}
-		var cmd tea.Cmd
-		a.columnInfoTable, cmd = a.columnInfoTable.Update(msg)
-		return a, cmd
+	if a.showingChildRefs {
+		return a.updateChildRefs(msg)
+	}
+
+	if a.showingProfile {
+		return a.updateColumnProfile(msg)
+	}
+
+	if a.showingDiff {
+		return a.updateResultDiff(msg)
+	}
+
+	if a.writeConfirming {
+		return a.updateWriteConfirm(msg)
 	}
 
 	if a.editConfirming {
@@ -98,6 +85,23 @@ func (a *App) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return a.updateResultSearch(msg)
 	}
 
+	if a.tabRenaming {
+		return a.updateTabRename(msg)
+	}
+
+	if a.watchPrompting {
+		return a.updateWatchPrompt(msg)
+	}
+
+	if handled, cmd := a.updateTabKeys(msg); handled {
+		return a, cmd
+	}
+
+	if msg.String() == "ctrl+s" {
+		a.startSessionSwitcher()
+		return a, nil
+	}
+
 	switch msg.String() {
 	case "ctrl+d":
 		if err := a.startRecordDelete(); err != nil {
@@ -109,6 +113,7 @@ func (a *App) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			a.clearResultFilter()
 			return a, nil
 		}
+		a.stopWatch()
 		a.mode = modeConnected
 		return a, nil
 	case "tab":
@@ -127,18 +132,19 @@ func (a *App) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if !a.queryFocused && a.queryResult != nil {
 			return a, a.startResultSearch()
 		}
+	case "alt+left":
+		a.navigateBack()
+		return a, nil
+	case "alt+right":
+		a.navigateForward()
+		return a, nil
 	case "ctrl+t":
-		tables, err := db.ListTables(a.db, a.selectedDatabase, a.dbType)
-		if err != nil {
+		if err := a.loadTableList(); err != nil {
 			a.queryErr = err
-			return a, nil
 		}
-		a.tables = tables
-		a.filteredTables = tables
-		a.tableCursor = 0
-		a.tableSearchInput.Reset()
-		a.tableSearching = false
-		a.mode = modeTableList
+		return a, nil
+	case "ctrl+x":
+		a.startExplain(false)
 		return a, nil
 	}
 
@@ -149,46 +155,102 @@ func (a *App) updateQuery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// executeQuery runs query with optional bind args against the selected
+// database and loads the result, recording the previous result in the
+// navigation history. When tableName is set, PK and FK metadata are looked
+// up so that editing, deleting and FK navigation work on the result.
+func (a *App) executeQuery(query, tableName string, args ...any) {
+	a.stopWatch()
+	a.pushNav()
+	a.queryInput.SetValue(query)
+	a.queryArgs = args
+	result, logErr, err := a.runAudited(query, args...)
+	if err != nil {
+		a.queryErr = err
+		a.queryResult = nil
+		a.filteredResultRows = nil
+		return
+	}
+
+	a.queryErr = nil
+	a.queryResult = result
+	a.resultFilter = ""
+	a.resultSearchInput.SetValue("")
+	a.resultSort = nil
+	a.filterResults()
+	a.resultCursor = 0
+	a.fieldCursor = 0
+	a.queryTableName = tableName
+	a.queryPKColumns = nil
+	a.queryFKRefs = nil
+	if tableName != "" {
+		var pkErr error
+		a.queryPKColumns, pkErr = db.GetPrimaryKey(a.db, a.selectedDatabase, tableName, a.dbType)
+		if pkErr != nil {
+			// Show PK lookup error so user knows why edit/delete won't work
+			a.queryErr = fmt.Errorf("PK lookup failed for %s: %v", tableName, pkErr)
+		}
+		a.queryFKRefs, _ = db.GetOutgoingFKs(a.db, a.selectedDatabase, tableName, a.dbType)
+	}
+	if logErr != nil {
+		a.queryErr = logErr
+	}
+}
+
+// runAudited runs query against the selected database. Write statements are
+// recorded in the audit log; those without a result set are run with Exec
+// and return the affected row count as their result. logErr is set when the
+// statement ran but could not be logged.
+func (a *App) runAudited(query string, args ...any) (result *db.QueryResult, logErr, err error) {
+	fullQuery := db.PrependUseDatabase(query, a.selectedDatabase, a.dbType)
+	if !db.IsWriteStatement(query) {
+		result, err = db.RunQuery(a.db, fullQuery, args...)
+		return result, nil, err
+	}
+	if db.ReturnsRows(query) {
+		result, err = db.RunQuery(a.db, fullQuery, args...)
+		return result, a.audit(query, -1, err), err
+	}
+	rows, err := db.Exec(a.db, fullQuery, args...)
+	logErr = a.audit(query, rows, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &db.QueryResult{
+		Columns:     []string{"rows_affected"},
+		ColumnTypes: []string{""},
+		Rows:        [][]string{{strconv.FormatInt(rows, 10)}},
+	}, logErr, nil
+}
+
 func (a *App) updateQueryInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "ctrl+c":
 		a.queryInput.SetValue("")
+		a.queryArgs = nil
+		a.stopWatch()
 		return a, nil
 	case "ctrl+e":
 		return a, a.openAdvancedQueryEditor()
 	case "enter":
 		query := a.queryInput.Value()
 		if query != "" {
-			fullQuery := db.PrependUseDatabase(query, a.selectedDatabase, a.dbType)
-			result, err := db.RunQuery(a.db, fullQuery)
-			if err != nil {
-				a.queryErr = err
-				a.queryResult = nil
-				a.filteredResultRows = nil
-			} else {
-				a.queryErr = nil
-				a.queryResult = result
-				a.resultFilter = ""
-				a.resultSearchInput.SetValue("")
-				a.filterResults()
-				a.resultCursor = 0
-				a.fieldCursor = 0
-				a.queryTableName = parseTableName(query)
-				a.queryPKColumns = nil
-				if a.queryTableName != "" && !hasJoin(query) {
-					var pkErr error
-					a.queryPKColumns, pkErr = db.GetPrimaryKey(a.db, a.selectedDatabase, a.queryTableName, a.dbType)
-					if pkErr != nil {
-						// Show PK lookup error so user knows why edit/delete won't work
-						a.queryErr = fmt.Errorf("PK lookup failed for %s: %v", a.queryTableName, pkErr)
-					}
-				}
+			tableName := ""
+			if !hasJoin(query) {
+				tableName = parseTableName(query)
 			}
+			// Bind args from a pushed-down filter still apply to the unchanged query
+			a.runStatement(query, tableName, a.queryArgs...)
 		}
 		return a, nil
 	}
+	prev := a.queryInput.Value()
 	var cmd tea.Cmd
 	a.queryInput, cmd = a.queryInput.Update(msg)
+	if a.queryInput.Value() != prev {
+		a.queryArgs = nil
+		a.stopWatch()
+	}
 	return a, cmd
 }
 
@@ -210,6 +272,46 @@ func (a *App) updateResultNavigation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if cmd := a.startFieldEdit(); cmd != nil {
 			return a, cmd
 		}
+	case "f":
+		if err := a.followFK(); err != nil {
+			a.queryErr = err
+		}
+	case "r":
+		if err := a.startChildRefs(); err != nil {
+			a.queryErr = err
+		}
+	case "F":
+		if err := a.pushFilterToServer(); err != nil {
+			a.queryErr = err
+		}
+	case "p":
+		a.startColumnProfile()
+	case "m":
+		return a, a.snapshotResult()
+	case "d":
+		if err := a.startResultDiff(); err != nil {
+			a.queryErr = err
+		}
+	case "w":
+		if a.watching {
+			a.stopWatch()
+			return a, nil
+		}
+		return a, a.startWatchPrompt()
+	case " ":
+		return a, a.toggleWatchPause()
+	case "s":
+		a.cycleSort()
+	case "S":
+		a.clearSort()
+	case "o":
+		if err := a.sortServerSide(); err != nil {
+			a.queryErr = err
+		}
+	case "[":
+		a.navigateBack()
+	case "]":
+		a.navigateForward()
 	case "ctrl+c":
 		if a.queryResult != nil && len(a.filteredResultRows) > 0 {
 			row := a.filteredResultRows[a.resultCursor]
@@ -231,25 +333,11 @@ func (a *App) updateResultNavigation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "?":
 		if a.queryTableName != "" {
-			info, err := db.GetColumnInfo(a.db, a.selectedDatabase, a.queryTableName, a.dbType)
-			if err == nil && len(info) > 0 {
-				a.columnInfoData = info
-				a.columnInfoFilter = ""
-				a.columnInfoSearchInput.SetValue("")
-				a.filteredColumnInfo = info
-				tableHeight := min(len(info), 15)
-				a.columnInfoTable = buildColumnInfoTable(info, tableHeight)
-				if a.queryResult != nil && a.fieldCursor < len(a.queryResult.Columns) {
-					selectedCol := a.queryResult.Columns[a.fieldCursor]
-					for i, col := range info {
-						if col.Name == selectedCol {
-							a.columnInfoTable.SetCursor(i)
-							break
-						}
-					}
-				}
-				a.showingColumnInfo = true
+			selectedCol := ""
+			if a.queryResult != nil && a.fieldCursor < len(a.queryResult.Columns) {
+				selectedCol = a.queryResult.Columns[a.fieldCursor]
 			}
+			a.openColumnInfo(a.queryTableName, selectedCol)
 		}
 	}
 	return a, nil
@@ -258,30 +346,32 @@ func (a *App) updateResultNavigation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) viewQuery() string {
 	var b strings.Builder
 
+	if a.showingExplain {
+		return a.viewExplain()
+	}
+
+	if a.showingDDL {
+		return a.viewTableDDL()
+	}
+
 	if a.showingColumnInfo {
-		b.WriteString(selectedStyle.Render("Column Info: " + a.queryTableName))
-		b.WriteString("\n\n")
+		return a.viewColumnInfo()
+	}
 
-		if a.columnInfoSearching {
-			b.WriteString(inputLabelStyle.Render("Filter: "))
-			b.WriteString(a.columnInfoSearchInput.View())
-			b.WriteString("\n\n")
-		} else if a.columnInfoFilter != "" {
-			b.WriteString(dimStyle.Render("Filter: " + a.columnInfoFilter + " (esc to clear)"))
-			b.WriteString("\n\n")
-		} else {
-			b.WriteString(dimStyle.Render("Filter: press / to filter"))
-			b.WriteString("\n\n")
-		}
+	if a.showingChildRefs {
+		return a.viewChildRefs()
+	}
 
-		if len(a.filteredColumnInfo) > 0 {
-			b.WriteString(a.columnInfoTable.View())
-		} else {
-			b.WriteString(dimStyle.Render("No columns match filter"))
-		}
+	if a.showingProfile {
+		return a.viewColumnProfile()
+	}
 
-		controls := "j/k: navigate • /: filter • esc/?: close"
-		return a.renderFrame(b.String(), controls)
+	if a.showingDiff {
+		return a.viewResultDiff()
+	}
+
+	if a.writeConfirming {
+		return a.viewWriteConfirm()
 	}
 
 	if a.editConfirming {
@@ -294,7 +384,12 @@ func (a *App) viewQuery() string {
 
 	b.WriteString("Database: ")
 	b.WriteString(selectedStyle.Render(a.selectedDatabase))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if crumb := a.breadcrumb(); crumb != "" {
+		b.WriteString(dimStyle.Render("Path: " + crumb))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
 
 	if a.queryFocused {
 		b.WriteString(inputLabelStyle.Render("> Query: "))
@@ -304,14 +399,31 @@ func (a *App) viewQuery() string {
 		b.WriteString(dimStyle.Render("  Query: " + a.queryInput.Value()))
 		b.WriteString("\n\n")
 	}
+	if len(a.queryArgs) > 0 {
+		b.WriteString(dimStyle.Render("  Params: " + formatQueryArgs(a.queryArgs)))
+		b.WriteString("\n\n")
+	}
+	if status := a.viewWatchStatus(); status != "" {
+		b.WriteString(status)
+		b.WriteString("\n\n")
+	}
 
 	if a.queryResult != nil {
 		if a.resultSearching {
 			b.WriteString(inputLabelStyle.Render("Filter: "))
 			b.WriteString(a.resultSearchInput.View())
+			b.WriteString("\n")
+			if a.resultFilterErr != nil {
+				b.WriteString(errorStyle.Render(a.resultFilterErr.Error()))
+			} else {
+				b.WriteString(dimStyle.Render(filterHint))
+			}
+			b.WriteString("\n\n")
+		} else if a.resultFilterErr != nil {
+			b.WriteString(errorStyle.Render("Filter: " + a.resultFilter + " (" + a.resultFilterErr.Error() + ")"))
 			b.WriteString("\n\n")
 		} else if a.resultFilter != "" {
-			b.WriteString(dimStyle.Render("Filter: " + a.resultFilter + " (esc to clear)"))
+			b.WriteString(dimStyle.Render("Filter: " + a.resultFilter + " (esc to clear, F to run on server)"))
 			b.WriteString("\n\n")
 		} else if !a.queryFocused {
 			b.WriteString(dimStyle.Render("Filter: press / to filter"))
@@ -348,20 +460,31 @@ func (a *App) viewQuery() string {
 			var line strings.Builder
 			line.WriteString("  ")
 			isSelected := !a.queryFocused && j == a.fieldCursor
+			sortMark := a.sortIndicator(col)
+			changed := a.isCellChanged(row, j)
+			valStyle := valueStyle
+			if changed {
+				valStyle = changedCellStyle
+			}
 			if isSelected {
-				line.WriteString(editingStyle.Render(fmt.Sprintf(`"%s"`, col)))
+				line.WriteString(editingStyle.Render(fmt.Sprintf(`"%s"`, col) + sortMark))
 				line.WriteString(": ")
 				if a.fieldEditing {
 					line.WriteString(a.fieldEditInput.View())
+				} else if changed {
+					line.WriteString(changedCellStyle.Render(fmt.Sprintf(`"%s"`, val)))
 				} else {
 					line.WriteString(editingStyle.Render(fmt.Sprintf(`"%s"`, val)))
 				}
 			} else {
-				line.WriteString(selectedStyle.Render(fmt.Sprintf(`"%s"`, col)))
+				line.WriteString(selectedStyle.Render(fmt.Sprintf(`"%s"`, col) + sortMark))
 				line.WriteString(": ")
-				line.WriteString(valueStyle.Render(fmt.Sprintf(`"%s"`, val)))
+				line.WriteString(valStyle.Render(fmt.Sprintf(`"%s"`, val)))
 			}
 			line.WriteString(comma)
+			if ref := a.fkRefForColumn(col); ref != nil {
+				line.WriteString(dimStyle.Render(fmt.Sprintf(" -> %s.%s", ref.ReferencedTable, ref.ReferencedColumn)))
+			}
 			fieldLines = append(fieldLines, line.String())
 		}
 
@@ -383,6 +506,9 @@ func (a *App) viewQuery() string {
 		if a.copySuccess {
 			b.WriteString(selectedStyle.Render(" Copied!"))
 		}
+		if a.snapshotTaken {
+			b.WriteString(selectedStyle.Render(" Snapshot saved!"))
+		}
 	} else if a.queryResult != nil {
 		b.WriteString(dimStyle.Render("No results"))
 	} else {
@@ -390,13 +516,24 @@ func (a *App) viewQuery() string {
 	}
 
 	var controls string
-	if a.fieldEditing {
+	if a.tabRenaming {
+		controls = "enter: rename tab • esc: cancel"
+	} else if a.fieldEditing {
 		controls = "enter: save • esc: cancel"
 	} else if !a.queryFocused && a.queryResult != nil && len(a.filteredResultRows) > 0 {
-		controls = "h/l: rows • j/k: fields • i: edit • ctrl+d: delete • ?: cols • ctrl+c: copy • /: filter • tab: query • esc: back"
+		controls = "h/l: rows • j/k: fields • i: edit • ctrl+d: delete • s/S: sort/clear • o: sort on server • p: profile • m/d: snapshot/diff • w/space: watch/pause • f: follow FK • r: referencing rows • [/]: back/fwd • ?: cols • ctrl+x: explain • ctrl+c: copy • /: filter • tab: query • esc: back"
 	} else {
-		controls = "enter: run • ctrl+e: editor • tab: results • ctrl+c: clear • ctrl+d: delete • ctrl+t: tables • esc: back"
+		controls = "enter: run • ctrl+e: editor • tab: results • ctrl+c: clear • ctrl+d: delete • ctrl+t: tables • ctrl+x: explain • alt+←/→: history • alt+n/w: new/close tab • shift+←/→: tabs • ctrl+r: rename tab • ctrl+s: connections • esc: back"
 	}
 
 	return a.renderFrame(b.String(), controls)
 }
+
+// formatQueryArgs renders bind args as $1='value' pairs for display
+func formatQueryArgs(args []any) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprintf("$%d='%v'", i+1, arg)
+	}
+	return strings.Join(parts, ", ")
+}
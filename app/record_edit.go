@@ -16,15 +16,13 @@ import (
 var editingStyle = lipgloss.NewStyle().Foreground(ColorWarning).Bold(true)
 
 func (a *App) generateUpdateSQL(tableName string, row []string, colIndex int, newValue string, pkColumns []string) string {
-	var b strings.Builder
-	b.WriteString("UPDATE ")
-	b.WriteString(db.FormatTableName(tableName, a.dbType))
-	b.WriteString(" SET ")
-	b.WriteString(a.queryResult.Columns[colIndex])
-	b.WriteString(" = '")
-	b.WriteString(strings.ReplaceAll(newValue, "'", "''"))
-	b.WriteString("' WHERE ")
+	return "UPDATE " + db.FormatTableName(tableName, a.dbType) + " SET " + a.queryResult.Columns[colIndex] +
+		" = '" + strings.ReplaceAll(newValue, "'", "''") + "' WHERE " + a.pkWhereClause(row, pkColumns)
+}
 
+// pkWhereClause matches row by its primary key columns
+func (a *App) pkWhereClause(row []string, pkColumns []string) string {
+	var b strings.Builder
 	first := true
 	for _, pkCol := range pkColumns {
 		for i, col := range a.queryResult.Columns {
@@ -45,20 +43,19 @@ func (a *App) generateUpdateSQL(tableName string, row []string, colIndex int, ne
 			}
 		}
 	}
-
 	return b.String()
 }
 
 func (a *App) updateEditConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
+	key, cmd := a.impactKey(msg)
+	switch key {
 	case "y", "Y":
-		fullQuery := db.PrependUseDatabase(a.pendingUpdateSQL, a.selectedDatabase, a.dbType)
-		_, err := db.RunQuery(a.db, fullQuery)
+		logErr, err := a.execConfirmed(a.pendingUpdateSQL)
 		if err != nil {
 			a.queryErr = err
 		} else {
 			query := db.PrependUseDatabase(a.queryInput.Value(), a.selectedDatabase, a.dbType)
-			result, err := db.RunQuery(a.db, query)
+			result, err := db.RunQuery(a.db, query, a.queryArgs...)
 			if err != nil {
 				a.queryErr = err
 			} else {
@@ -67,15 +64,20 @@ func (a *App) updateEditConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				a.filterResults()
 			}
 		}
+		if logErr != nil {
+			a.queryErr = logErr
+		}
 		a.editConfirming = false
 		a.pendingUpdateSQL = ""
+		a.clearImpact()
 		return a, nil
 	case "n", "N", "esc":
 		a.editConfirming = false
 		a.pendingUpdateSQL = ""
+		a.clearImpact()
 		return a, nil
 	}
-	return a, nil
+	return a, cmd
 }
 
 func (a *App) updateFieldEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -104,6 +106,8 @@ func (a *App) updateFieldEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 			row := a.filteredResultRows[a.resultCursor]
 			a.pendingUpdateSQL = a.generateUpdateSQL(a.queryTableName, row, a.fieldCursor, newValue, a.queryPKColumns)
+			a.prepareImpact(a.queryTableName, a.pkWhereClause(row, a.queryPKColumns))
+			a.loadConfirmAccess()
 			a.editConfirming = true
 		}
 		a.fieldEditing = false
@@ -116,6 +120,10 @@ func (a *App) updateFieldEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (a *App) startFieldEdit() tea.Cmd {
+	if a.readOnly() {
+		a.queryErr = fmt.Errorf("%w: editing is disabled", errReadOnly)
+		return nil
+	}
 	if a.queryResult != nil && len(a.filteredResultRows) > 0 {
 		row := a.filteredResultRows[a.resultCursor]
 		if a.fieldCursor < len(row) {
@@ -135,43 +143,25 @@ func (a *App) viewEditConfirm() string {
 	b.WriteString("\n\n")
 	b.WriteString(dimStyle.Render(a.pendingUpdateSQL))
 	b.WriteString("\n\n")
-	b.WriteString("Execute this query? ")
-	b.WriteString(selectedStyle.Render("(y/n)"))
-	controls := "y: execute • n/esc: cancel"
-	return a.renderFrame(b.String(), controls)
+	b.WriteString(a.viewConfirmAccess("UPDATE"))
+	b.WriteString(a.viewImpact("UPDATE"))
+	if !a.impactTyping && a.impactErr == nil {
+		b.WriteString("Execute this query? ")
+		b.WriteString(selectedStyle.Render("(y/n)"))
+	}
+	return a.renderFrame(b.String(), a.impactControls())
 }
 
 func (a *App) generateDeleteSQL(tableName string, row []string, pkColumns []string) string {
-	var b strings.Builder
-	b.WriteString("DELETE FROM ")
-	b.WriteString(db.FormatTableName(tableName, a.dbType))
-	b.WriteString(" WHERE ")
-
-	first := true
-	for _, pkCol := range pkColumns {
-		for i, col := range a.queryResult.Columns {
-			if col == pkCol {
-				if !first {
-					b.WriteString(" AND ")
-				}
-				first = false
-				b.WriteString(col)
-				if row[i] == "NULL" {
-					b.WriteString(" IS NULL")
-				} else {
-					b.WriteString(" = '")
-					b.WriteString(strings.ReplaceAll(row[i], "'", "''"))
-					b.WriteString("'")
-				}
-				break
-			}
-		}
-	}
-
-	return b.String()
+	return "DELETE FROM " + db.FormatTableName(tableName, a.dbType) + " WHERE " + a.pkWhereClause(row, pkColumns)
 }
 
 func (a *App) generateMultiDeleteSQL(tableName string, rows [][]string, pkColumns []string) string {
+	return "DELETE FROM " + db.FormatTableName(tableName, a.dbType) + " WHERE " + a.multiPKWhereClause(rows, pkColumns)
+}
+
+// multiPKWhereClause matches rows by their primary key columns
+func (a *App) multiPKWhereClause(rows [][]string, pkColumns []string) string {
 	if len(pkColumns) == 1 {
 		// Simple IN clause for single PK
 		pkCol := pkColumns[0]
@@ -191,7 +181,7 @@ func (a *App) generateMultiDeleteSQL(tableName string, rows [][]string, pkColumn
 			values = append(values, "'"+strings.ReplaceAll(row[pkIndex], "'", "''")+"'")
 		}
 
-		return fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", db.FormatTableName(tableName, a.dbType), pkCol, strings.Join(values, ", "))
+		return fmt.Sprintf("%s IN (%s)", pkCol, strings.Join(values, ", "))
 	}
 
 	// Composite PK - use OR conditions
@@ -213,19 +203,19 @@ func (a *App) generateMultiDeleteSQL(tableName string, rows [][]string, pkColumn
 		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
 	}
 
-	return fmt.Sprintf("DELETE FROM %s WHERE %s", db.FormatTableName(tableName, a.dbType), strings.Join(conditions, " OR "))
+	return strings.Join(conditions, " OR ")
 }
 
 func (a *App) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
+	key, cmd := a.impactKey(msg)
+	switch key {
 	case "y", "Y":
-		fullQuery := db.PrependUseDatabase(a.pendingDeleteSQL, a.selectedDatabase, a.dbType)
-		_, err := db.RunQuery(a.db, fullQuery)
+		logErr, err := a.execConfirmed(a.pendingDeleteSQL)
 		if err != nil {
 			a.queryErr = err
 		} else {
 			query := db.PrependUseDatabase(a.queryInput.Value(), a.selectedDatabase, a.dbType)
-			result, err := db.RunQuery(a.db, query)
+			result, err := db.RunQuery(a.db, query, a.queryArgs...)
 			if err != nil {
 				a.queryErr = err
 			} else {
@@ -237,11 +227,15 @@ func (a *App) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
+		if logErr != nil {
+			a.queryErr = logErr
+		}
 		a.deleteConfirming = false
 		a.pendingDeleteSQL = ""
 		a.fkDependencies = nil
 		a.fkDependencyCounts = nil
 		a.deletingMultiple = false
+		a.clearImpact()
 		return a, nil
 	case "n", "N", "esc":
 		a.deleteConfirming = false
@@ -249,6 +243,7 @@ func (a *App) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		a.fkDependencies = nil
 		a.fkDependencyCounts = nil
 		a.deletingMultiple = false
+		a.clearImpact()
 		return a, nil
 	case "j", "down":
 		if len(a.fkDependencies) > 0 {
@@ -266,10 +261,13 @@ func (a *App) updateDeleteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return a, nil
 	}
-	return a, nil
+	return a, cmd
 }
 
 func (a *App) startRecordDelete() error {
+	if a.readOnly() {
+		return fmt.Errorf("%w: deleting is disabled", errReadOnly)
+	}
 	if a.queryResult == nil || len(a.filteredResultRows) == 0 {
 		return fmt.Errorf("no record selected")
 	}
@@ -285,11 +283,13 @@ func (a *App) startRecordDelete() error {
 		a.deletingMultiple = true
 		a.deleteRowCount = len(a.filteredResultRows)
 		a.pendingDeleteSQL = a.generateMultiDeleteSQL(a.queryTableName, a.filteredResultRows, a.queryPKColumns)
+		a.prepareImpact(a.queryTableName, a.multiPKWhereClause(a.filteredResultRows, a.queryPKColumns))
 	} else {
 		a.deletingMultiple = false
 		a.deleteRowCount = 1
 		row := a.filteredResultRows[a.resultCursor]
 		a.pendingDeleteSQL = a.generateDeleteSQL(a.queryTableName, row, a.queryPKColumns)
+		a.prepareImpact(a.queryTableName, a.pkWhereClause(row, a.queryPKColumns))
 	}
 
 	// Query FK dependencies
@@ -309,6 +309,7 @@ func (a *App) startRecordDelete() error {
 		}
 	}
 
+	a.loadConfirmAccess()
 	a.deleteConfirming = true
 	return nil
 }
@@ -386,24 +387,7 @@ func (a *App) queryFKDependency(dep db.FKDependency) {
 	query := fmt.Sprintf("SELECT * FROM %s WHERE %s IN (%s)",
 		dep.TableName, dep.ColumnName, strings.Join(values, ", "))
 
-	a.queryInput.SetValue(query)
-	fullQuery := db.PrependUseDatabase(query, a.selectedDatabase, a.dbType)
-	result, err := db.RunQuery(a.db, fullQuery)
-	if err != nil {
-		a.queryErr = err
-		a.queryResult = nil
-		a.filteredResultRows = nil
-	} else {
-		a.queryErr = nil
-		a.queryResult = result
-		a.resultFilter = ""
-		a.resultSearchInput.SetValue("")
-		a.filterResults()
-		a.resultCursor = 0
-		a.fieldCursor = 0
-		a.queryTableName = dep.TableName
-		a.queryPKColumns, _ = db.GetPrimaryKey(a.db, a.selectedDatabase, dep.TableName, a.dbType)
-	}
+	a.executeQuery(query, dep.TableName)
 
 	// Exit delete confirmation
 	a.deleteConfirming = false
@@ -467,14 +451,20 @@ func (a *App) viewDeleteConfirm() string {
 	}
 
 	b.WriteString("\n")
-	b.WriteString("Execute this query? ")
-	b.WriteString(errorStyle.Render("(y/n)"))
+	b.WriteString(a.viewConfirmAccess("DELETE"))
+	b.WriteString(a.viewImpact("DELETE"))
+	if !a.impactTyping && a.impactErr == nil {
+		b.WriteString("Execute this query? ")
+		b.WriteString(errorStyle.Render("(y/n)"))
+	}
 
-	var controls string
+	controls := a.impactControls()
 	if hasRefs {
-		controls = "y: execute • n/esc: cancel • j/k: navigate • enter: query dependency"
-	} else {
-		controls = "y: execute • n/esc: cancel"
+		if a.impactTyping {
+			controls += " • ↑/↓: navigate"
+		} else {
+			controls += " • j/k: navigate • enter: query dependency"
+		}
 	}
 	return a.renderFrame(b.String(), controls)
 }
@@ -0,0 +1,270 @@
+// result_diff.go compares two query results. The user snapshots the current
+// result, runs something else, and diffs the new result against the snapshot.
+// Rows are matched on primary key columns when both results contain them,
+// otherwise on full-row equality, and reported as added, removed or changed.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	diffAdded   = "+"
+	diffRemoved = "-"
+	diffChanged = "~"
+)
+
+type clearSnapshotMsg struct{}
+
+type rowDiff struct {
+	kind    string
+	label   string
+	before  []string // Aligned to the snapshot's columns
+	after   []string // Aligned to the current result's columns
+	changed map[string]bool
+}
+
+type resultDiff struct {
+	rows      []rowDiff
+	unchanged int
+	keyedBy   []string // PK columns used to match rows, empty for full-row matching
+}
+
+// diffResults compares after against before. PK matching is used when every
+// pkColumn is present in both results.
+func diffResults(before, after *db.QueryResult, pkColumns []string) resultDiff {
+	beforeIdx := columnPositions(before.Columns)
+	afterIdx := columnPositions(after.Columns)
+
+	keyed := len(pkColumns) > 0
+	for _, pk := range pkColumns {
+		_, inBefore := beforeIdx[pk]
+		_, inAfter := afterIdx[pk]
+		keyed = keyed && inBefore && inAfter
+	}
+	if !keyed {
+		return diffFullRows(before, after)
+	}
+
+	keyOf := func(row []string, idx map[string]int) string {
+		var parts []string
+		for _, pk := range pkColumns {
+			parts = append(parts, pk+"="+row[idx[pk]])
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	d := resultDiff{keyedBy: pkColumns}
+	beforeRows := make(map[string][]string, len(before.Rows))
+	for _, row := range before.Rows {
+		beforeRows[keyOf(row, beforeIdx)] = row
+	}
+	seen := make(map[string]bool)
+	for _, row := range after.Rows {
+		key := keyOf(row, afterIdx)
+		seen[key] = true
+		old, ok := beforeRows[key]
+		if !ok {
+			d.rows = append(d.rows, rowDiff{kind: diffAdded, label: key, after: row})
+			continue
+		}
+		changed := make(map[string]bool)
+		for col, i := range afterIdx {
+			if j, ok := beforeIdx[col]; ok && old[j] != row[i] {
+				changed[col] = true
+			}
+		}
+		if len(changed) == 0 {
+			d.unchanged++
+			continue
+		}
+		d.rows = append(d.rows, rowDiff{kind: diffChanged, label: key, before: old, after: row, changed: changed})
+	}
+	for _, row := range before.Rows {
+		if key := keyOf(row, beforeIdx); !seen[key] {
+			d.rows = append(d.rows, rowDiff{kind: diffRemoved, label: key, before: row})
+		}
+	}
+	return d
+}
+
+// diffFullRows matches rows by their complete contents, treating each result
+// as a multiset. Without a key there are no "changed" rows.
+func diffFullRows(before, after *db.QueryResult) resultDiff {
+	var d resultDiff
+	remaining := make(map[string]int)
+	for _, row := range before.Rows {
+		remaining[strings.Join(row, "\x00")]++
+	}
+	for _, row := range after.Rows {
+		key := strings.Join(row, "\x00")
+		if remaining[key] > 0 {
+			remaining[key]--
+			d.unchanged++
+			continue
+		}
+		d.rows = append(d.rows, rowDiff{kind: diffAdded, label: rowLabel(row), after: row})
+	}
+	for _, row := range before.Rows {
+		key := strings.Join(row, "\x00")
+		if remaining[key] > 0 {
+			remaining[key]--
+			d.rows = append(d.rows, rowDiff{kind: diffRemoved, label: rowLabel(row), before: row})
+		}
+	}
+	return d
+}
+
+func columnPositions(columns []string) map[string]int {
+	idx := make(map[string]int, len(columns))
+	for i, col := range columns {
+		idx[col] = i
+	}
+	return idx
+}
+
+func rowLabel(row []string) string {
+	return truncate(strings.Join(row, ", "), 40)
+}
+
+func (d resultDiff) count(kind string) int {
+	n := 0
+	for _, r := range d.rows {
+		if r.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// snapshotResult stores the current result as the baseline for diffing
+func (a *App) snapshotResult() tea.Cmd {
+	if a.queryResult == nil {
+		return nil
+	}
+	a.diffSnapshot = a.queryResult
+	a.diffSnapshotPK = a.queryPKColumns
+	a.diffSnapshotQuery = a.queryInput.Value()
+	a.snapshotTaken = true
+	return tea.Tick(2*time.Second, func(t time.Time) tea.Msg {
+		return clearSnapshotMsg{}
+	})
+}
+
+// startResultDiff diffs the current result against the snapshot
+func (a *App) startResultDiff() error {
+	if a.diffSnapshot == nil {
+		return fmt.Errorf("no snapshot, press m to snapshot a result first")
+	}
+	if a.queryResult == nil {
+		return fmt.Errorf("no result to compare")
+	}
+	// Rows are matched by the snapshot's key, falling back to the current
+	// result's when the snapshot had none
+	pk := a.diffSnapshotPK
+	if len(pk) == 0 {
+		pk = a.queryPKColumns
+	}
+	a.diff = diffResults(a.diffSnapshot, a.queryResult, pk)
+	a.diffCursor = 0
+	a.showingDiff = true
+	return nil
+}
+
+func (a *App) updateResultDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "d":
+		a.showingDiff = false
+	case "j", "down":
+		a.diffCursor = moveCursor(a.diffCursor, 1, len(a.diff.rows))
+	case "k", "up":
+		a.diffCursor = moveCursor(a.diffCursor, -1, len(a.diff.rows))
+	}
+	return a, nil
+}
+
+func (a *App) viewResultDiff() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Diff vs snapshot"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render(a.diffSnapshotQuery))
+	b.WriteString("\n\n")
+
+	d := a.diff
+	b.WriteString(selectedStyle.Render(fmt.Sprintf("+%d added", d.count(diffAdded))))
+	b.WriteString("  ")
+	b.WriteString(errorStyle.Render(fmt.Sprintf("-%d removed", d.count(diffRemoved))))
+	b.WriteString("  ")
+	b.WriteString(editingStyle.Render(fmt.Sprintf("~%d changed", d.count(diffChanged))))
+	b.WriteString(dimStyle.Render(fmt.Sprintf("  (%d unchanged)", d.unchanged)))
+	b.WriteString("\n")
+	if len(d.keyedBy) > 0 {
+		b.WriteString(dimStyle.Render("matched on " + strings.Join(d.keyedBy, ", ")))
+	} else {
+		b.WriteString(dimStyle.Render("matched on full rows"))
+	}
+	b.WriteString("\n\n")
+
+	if len(d.rows) == 0 {
+		b.WriteString(dimStyle.Render("Results are identical"))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+
+	var lines []string
+	for i, r := range d.rows {
+		style := selectedStyle
+		switch r.kind {
+		case diffRemoved:
+			style = errorStyle
+		case diffChanged:
+			style = editingStyle
+		}
+		prefix := "  "
+		if i == a.diffCursor {
+			prefix = "> "
+		}
+		lines = append(lines, prefix+style.Render(r.kind+" "+r.label))
+	}
+	listHeight := min(len(lines), 8)
+	start := max(0, min(a.diffCursor-listHeight+1, len(lines)-listHeight))
+	b.WriteString(strings.Join(lines[start:start+listHeight], "\n"))
+	b.WriteString("\n\n")
+
+	b.WriteString(a.viewRowDiffDetail(d.rows[a.diffCursor]))
+
+	controls := "j/k: navigate • esc: close"
+	return a.renderFrame(b.String(), controls)
+}
+
+// viewRowDiffDetail shows every field of the selected row, highlighting
+// changed cells as old → new
+func (a *App) viewRowDiffDetail(r rowDiff) string {
+	var lines []string
+	switch r.kind {
+	case diffAdded:
+		for i, col := range a.queryResult.Columns {
+			lines = append(lines, fmt.Sprintf("  %s: %s", selectedStyle.Render(col), valueStyle.Render(r.after[i])))
+		}
+	case diffRemoved:
+		for i, col := range a.diffSnapshot.Columns {
+			lines = append(lines, fmt.Sprintf("  %s: %s", errorStyle.Render(col), dimStyle.Render(r.before[i])))
+		}
+	case diffChanged:
+		beforeIdx := columnPositions(a.diffSnapshot.Columns)
+		for i, col := range a.queryResult.Columns {
+			if r.changed[col] {
+				old := r.before[beforeIdx[col]]
+				lines = append(lines, fmt.Sprintf("  %s: %s → %s", editingStyle.Render(col),
+					dimStyle.Render(old), changedCellStyle.Render(r.after[i])))
+			} else {
+				lines = append(lines, fmt.Sprintf("  %s: %s", selectedStyle.Render(col), valueStyle.Render(r.after[i])))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,9 +1,12 @@
 // result_filter.go handles real-time filtering of query results.
-// It filters rows by matching search text against any column value.
+// It filters rows by plain text across all columns or by column expressions
+// (see filter_expr.go). For plain table queries the filter can also be pushed
+// down to the database as a parameterized WHERE clause.
 package app
 
 import (
-	"strings"
+	"fmt"
+	"regexp"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,22 +18,30 @@ func (a *App) filterResults() {
 		return
 	}
 
+	a.resultFilterErr = nil
 	if a.resultFilter == "" {
 		a.filteredResultRows = a.queryResult.Rows
+		a.applySort()
+		return
+	}
+
+	expr, err := parseFilter(a.resultFilter, a.queryResult.Columns)
+	a.resultFilterErr = err
+	if err != nil {
+		// Keep showing every row until the filter parses
+		a.filteredResultRows = a.queryResult.Rows
+		a.applySort()
 		return
 	}
 
-	filter := strings.ToLower(a.resultFilter)
 	filtered := [][]string{}
 	for _, row := range a.queryResult.Rows {
-		for _, col := range row {
-			if strings.Contains(strings.ToLower(col), filter) {
-				filtered = append(filtered, row)
-				break
-			}
+		if expr.matches(row) {
+			filtered = append(filtered, row)
 		}
 	}
 	a.filteredResultRows = filtered
+	a.applySort()
 	if a.resultCursor >= len(a.filteredResultRows) {
 		a.resultCursor = max(0, len(a.filteredResultRows)-1)
 	}
@@ -65,3 +76,30 @@ func (a *App) clearResultFilter() {
 	a.resultSearchInput.SetValue("")
 	a.filterResults()
 }
+
+var simpleSelectRe = regexp.MustCompile(`(?i)^\s*SELECT\s+\*\s+FROM\s+([^\s;]+)\s*;?\s*$`)
+
+// pushFilterToServer re-runs a plain SELECT * FROM table query with the
+// current filter as a WHERE clause, so filtering covers the whole table
+// rather than only the fetched rows
+func (a *App) pushFilterToServer() error {
+	if a.queryResult == nil || a.resultFilter == "" {
+		return fmt.Errorf("no filter to apply")
+	}
+	m := simpleSelectRe.FindStringSubmatch(a.queryInput.Value())
+	if m == nil {
+		return fmt.Errorf("filter pushdown only works on SELECT * FROM table queries")
+	}
+	expr, err := parseFilter(a.resultFilter, a.queryResult.Columns)
+	if err != nil {
+		return err
+	}
+	where, args, err := expr.whereClause(a.queryResult.Columns, a.dbType)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s", m[1], where)
+	a.executeQuery(query, a.queryTableName, args...)
+	return nil
+}
@@ -0,0 +1,195 @@
+// result_sort.go handles sorting query results. Pressing s on a field cycles
+// it through ascending, descending and off; sorting several fields builds a
+// multi-column sort in the order they were added. Comparisons use the column
+// types reported by the driver, falling back to inferring numbers and dates.
+// Table-browse queries can also be re-issued with ORDER BY so sorting covers
+// rows that were not fetched.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tableBrowseRe matches SELECT * FROM table with an optional WHERE clause (as
+// produced by filter pushdown) and an optional ORDER BY that will be replaced
+var tableBrowseRe = regexp.MustCompile(`(?is)^\s*(SELECT\s+\*\s+FROM\s+[^\s;]+(?:\s+WHERE\s+.+?)?)(?:\s+ORDER\s+BY\s+.+?)?\s*;?\s*$`)
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// cycleSort moves the field under the cursor through asc -> desc -> off
+func (a *App) cycleSort() {
+	if a.queryResult == nil || a.fieldCursor >= len(a.queryResult.Columns) {
+		return
+	}
+	col := a.queryResult.Columns[a.fieldCursor]
+	// Copy so history entries sharing the slice keep their own sort
+	a.resultSort = slices.Clone(a.resultSort)
+	i := slices.IndexFunc(a.resultSort, func(k sortKey) bool { return k.column == col })
+	switch {
+	case i == -1:
+		a.resultSort = append(a.resultSort, sortKey{column: col})
+	case !a.resultSort[i].desc:
+		a.resultSort[i].desc = true
+	default:
+		a.resultSort = slices.Delete(a.resultSort, i, i+1)
+	}
+	a.filterResults()
+}
+
+func (a *App) clearSort() {
+	a.resultSort = nil
+	a.filterResults()
+}
+
+// applySort orders filteredResultRows by the active sort keys. Rows are
+// copied first so turning sorting off restores the original order.
+func (a *App) applySort() {
+	if len(a.resultSort) == 0 || a.queryResult == nil {
+		return
+	}
+
+	type keyIndex struct {
+		idx  int
+		typ  string
+		desc bool
+	}
+	var keys []keyIndex
+	for _, k := range a.resultSort {
+		idx := a.columnIndex(k.column)
+		if idx == -1 {
+			continue
+		}
+		typ := ""
+		if idx < len(a.queryResult.ColumnTypes) {
+			typ = a.queryResult.ColumnTypes[idx]
+		}
+		keys = append(keys, keyIndex{idx, typ, k.desc})
+	}
+
+	sorted := slices.Clone(a.filteredResultRows)
+	slices.SortStableFunc(sorted, func(x, y []string) int {
+		for _, k := range keys {
+			c := compareTyped(x[k.idx], y[k.idx], k.typ)
+			if c == 0 {
+				continue
+			}
+			// NULLs always sort last
+			if x[k.idx] == "NULL" || y[k.idx] == "NULL" {
+				return c
+			}
+			if k.desc {
+				return -c
+			}
+			return c
+		}
+		return 0
+	})
+	a.filteredResultRows = sorted
+}
+
+// compareTyped compares two values according to the column's database type.
+// NULL compares greater than any value.
+func compareTyped(a, b, typ string) int {
+	switch {
+	case a == "NULL" && b == "NULL":
+		return 0
+	case a == "NULL":
+		return 1
+	case b == "NULL":
+		return -1
+	}
+
+	switch {
+	case typ == "":
+		return compareValues(a, b)
+	case isNumericType(typ):
+		fa, errA := strconv.ParseFloat(a, 64)
+		fb, errB := strconv.ParseFloat(b, 64)
+		if errA == nil && errB == nil {
+			switch {
+			case fa < fb:
+				return -1
+			case fa > fb:
+				return 1
+			}
+			return 0
+		}
+	case isDateType(typ):
+		ta, okA := parseDate(a)
+		tb, okB := parseDate(b)
+		if okA && okB {
+			return ta.Compare(tb)
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func isNumericType(typ string) bool {
+	switch strings.TrimPrefix(strings.ToUpper(typ), "UNSIGNED ") {
+	case "INT", "INT2", "INT4", "INT8", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT",
+		"DECIMAL", "NUMERIC", "FLOAT", "FLOAT4", "FLOAT8", "REAL", "DOUBLE", "MONEY", "SMALLMONEY":
+		return true
+	}
+	return false
+}
+
+func isDateType(typ string) bool {
+	switch strings.ToUpper(typ) {
+	case "DATE", "TIME", "TIMETZ", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET",
+		"TIMESTAMP", "TIMESTAMPTZ":
+		return true
+	}
+	return false
+}
+
+// sortIndicator returns the arrow and position shown next to a sorted field
+func (a *App) sortIndicator(col string) string {
+	for i, k := range a.resultSort {
+		if k.column == col {
+			arrow := "↑"
+			if k.desc {
+				arrow = "↓"
+			}
+			if len(a.resultSort) > 1 {
+				return fmt.Sprintf(" %s%d", arrow, i+1)
+			}
+			return " " + arrow
+		}
+	}
+	return ""
+}
+
+// sortServerSide re-issues a table-browse query with ORDER BY for the active
+// sort keys, so the sort covers the whole table
+func (a *App) sortServerSide() error {
+	if len(a.resultSort) == 0 {
+		return fmt.Errorf("no sort to apply, press s on a field first")
+	}
+	m := tableBrowseRe.FindStringSubmatch(a.queryInput.Value())
+	if m == nil {
+		return fmt.Errorf("server-side sort only works on SELECT * FROM table queries")
+	}
+
+	var parts []string
+	for _, k := range a.resultSort {
+		dir := "ASC"
+		if k.desc {
+			dir = "DESC"
+		}
+		parts = append(parts, db.QuoteIdent(k.column, a.dbType)+" "+dir)
+	}
+
+	keys := a.resultSort
+	query := m[1] + " ORDER BY " + strings.Join(parts, ", ")
+	a.executeQuery(query, a.queryTableName, a.queryArgs...)
+	a.resultSort = keys
+	return nil
+}
@@ -0,0 +1,167 @@
+// safety.go enforces per-connection safety levels. Read-only connections
+// refuse edits, deletes and write statements, and are opened with a
+// read-only session at the driver level where the engine supports it.
+// Confirm-writes connections ask before running any typed write statement.
+// Production connections get a red frame and a badge in the status line.
+package app
+
+import (
+	"database/sql"
+	"dbsurf/config"
+	"dbsurf/db"
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var errReadOnly = errors.New("connection is read-only")
+
+var (
+	productionBadgeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("15")).
+				Background(ColorError).
+				Bold(true)
+
+	safetyBadgeStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("0")).
+				Background(ColorWarning)
+)
+
+// connectSaved opens a saved connection
+func (a *App) connectSaved(conn config.Connection) (*sql.DB, error) {
+	opts, err := a.connectOptions(conn)
+	if err != nil {
+		return nil, err
+	}
+	return db.ConnectWith(conn.ConnString, opts)
+}
+
+// connectOptions returns what db.ConnectWith applies to a saved connection:
+// its password from the secret backend and its driver-level safety. The
+// connection string itself is left for db to expand.
+func (a *App) connectOptions(conn config.Connection) (db.ConnectOptions, error) {
+	password, err := a.config.Password(conn)
+	if err != nil {
+		return db.ConnectOptions{}, err
+	}
+	return db.ConnectOptions{
+		Password: password,
+		ReadOnly: conn.Safety == config.SafetyReadOnly,
+	}, nil
+}
+
+// currentConn returns the active session's connection, or the zero
+// (unrestricted) connection when none is open
+func (a *App) currentConn() config.Connection {
+	if a.activeSession >= 0 && a.activeSession < len(a.sessions) {
+		return a.sessions[a.activeSession].conn
+	}
+	return config.Connection{}
+}
+
+func (a *App) readOnly() bool {
+	return a.currentConn().Safety == config.SafetyReadOnly
+}
+
+// safetyBadges renders the production and safety level badges for conn
+func safetyBadges(conn config.Connection) string {
+	var badges []string
+	if conn.Production {
+		badges = append(badges, productionBadgeStyle.Render(" PROD "))
+	}
+	if conn.Safety != config.SafetyUnrestricted {
+		badges = append(badges, safetyBadgeStyle.Render(" "+strings.ToUpper(conn.Safety)+" "))
+	}
+	return strings.Join(badges, " ")
+}
+
+// checkWrite applies the connection's safety level to user SQL about to be
+// executed. Writes are refused on read-only connections; confirm reports
+// that a write on a confirm-writes connection must be confirmed first.
+// Every path that executes typed SQL goes through here.
+func (a *App) checkWrite(query string) (confirm bool, err error) {
+	if !db.IsWriteStatement(query) {
+		return false, nil
+	}
+	switch a.currentConn().Safety {
+	case config.SafetyReadOnly:
+		return false, fmt.Errorf("%w: write statements are disabled", errReadOnly)
+	case config.SafetyConfirmWrites:
+		return true, nil
+	}
+	return false, nil
+}
+
+// runStatement executes a typed statement subject to the connection's
+// safety level: writes are refused on read-only connections and held for
+// confirmation on confirm-writes connections
+func (a *App) runStatement(query, tableName string, args ...any) {
+	confirm, err := a.checkWrite(query)
+	if err != nil {
+		a.queryErr = err
+		a.audit(query, 0, err)
+		return
+	}
+	if confirm {
+		a.confirmWrite(query, tableName, false, args)
+		return
+	}
+	a.executeQuery(query, tableName, args...)
+}
+
+// confirmWrite holds a write until the user confirms it. explain runs it
+// with EXPLAIN ANALYZE instead of as a statement.
+func (a *App) confirmWrite(query, tableName string, explain bool, args []any) {
+	a.pendingWriteSQL = query
+	a.pendingWriteTable = tableName
+	a.pendingWriteArgs = args
+	a.pendingWriteExplain = explain
+	a.writeConfirming = true
+}
+
+func (a *App) updateWriteConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		a.writeConfirming = false
+		if a.pendingWriteExplain {
+			a.runExplain(a.pendingWriteSQL, true, a.pendingWriteArgs...)
+		} else {
+			a.executeQuery(a.pendingWriteSQL, a.pendingWriteTable, a.pendingWriteArgs...)
+		}
+	case "n", "N", "esc":
+		a.writeConfirming = false
+	default:
+		return a, nil
+	}
+	a.pendingWriteSQL = ""
+	a.pendingWriteTable = ""
+	a.pendingWriteArgs = nil
+	a.pendingWriteExplain = false
+	return a, nil
+}
+
+func (a *App) viewWriteConfirm() string {
+	var b strings.Builder
+	b.WriteString(errorStyle.Render("Confirm write on " + a.currentConn().Name))
+	b.WriteString("\n\n")
+	statement := a.pendingWriteSQL
+	if a.pendingWriteExplain {
+		statement = "EXPLAIN ANALYZE " + statement
+	}
+	b.WriteString(dimStyle.Render(statement))
+	b.WriteString("\n\n")
+	b.WriteString("This connection requires confirmation for statements that change data. Execute? ")
+	b.WriteString(errorStyle.Render("(y/n)"))
+	return a.renderFrame(b.String(), "y: execute • n/esc: cancel")
+}
+
+// syncSessionConn copies changed settings of a saved connection into its
+// open session. The driver-level read-only setting applies on next connect.
+func (a *App) syncSessionConn(conn config.Connection) {
+	if i := a.findSession(conn); i != -1 {
+		a.sessions[i].conn = conn
+	}
+}
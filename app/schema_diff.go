@@ -0,0 +1,273 @@
+// schema_diff.go compares the schema of the current database with another
+// database, either on this connection or on another saved connection of the
+// same engine. The user picks a connection and then a database; the diff runs
+// in the background and is shown as a report that can be exported as text or
+// as a best-effort migration script.
+package app
+
+import (
+	"dbsurf/config"
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type schemaDiffMsg struct {
+	diff *db.SchemaDiff
+	err  error
+}
+
+// schemaDiffTargets lists the saved connections with the same engine as the
+// active one, including the active one itself
+func (a *App) schemaDiffTargets() []config.Connection {
+	var targets []config.Connection
+	for _, conn := range a.config.Connections {
+		if conn.DBType == a.dbType {
+			targets = append(targets, conn)
+		}
+	}
+	return targets
+}
+
+func (a *App) startSchemaDiff() {
+	a.schemaCursor = 0
+	a.schemaDiffErr = nil
+	a.schemaPickingConn = true
+}
+
+// pickSchemaDiffConn resolves the chosen connection to a *sql.DB, reusing an
+// open session where possible, then moves on to picking the database.
+// Postgres connections are bound to one database so go straight to the diff.
+func (a *App) pickSchemaDiffConn(conn config.Connection) tea.Cmd {
+	a.schemaPickingConn = false
+	a.schemaTarget = db.TableSide{DBType: conn.DBType}
+	a.schemaTargetName = conn.Name
+	a.schemaTargetOwned = false
+
+	if i := a.findSession(conn); i != -1 {
+		a.schemaTarget.DB = a.sessions[i].db
+	} else {
+		target, err := a.connectSaved(conn)
+		if err != nil {
+			a.schemaDiffErr = err
+			a.showingSchemaDiff = true
+			return nil
+		}
+		a.schemaTarget.DB = target
+		a.schemaTargetOwned = true
+	}
+
+	if conn.DBType == "postgres" {
+		return a.runSchemaDiff()
+	}
+
+	dbs, err := db.ListDatabases(a.schemaTarget.DB, conn.DBType)
+	if err != nil {
+		a.schemaDiffErr = err
+		a.showingSchemaDiff = true
+		return nil
+	}
+	a.schemaTargetDBs = dbs
+	a.schemaCursor = 0
+	a.schemaPickingDB = true
+	return nil
+}
+
+// runSchemaDiff loads both schemas and diffs them in the background
+func (a *App) runSchemaDiff() tea.Cmd {
+	source := db.TableSide{DB: a.db, DBName: a.selectedDatabase, DBType: a.dbType}
+	target := a.schemaTarget
+	a.schemaDiffing = true
+
+	return tea.Batch(func() tea.Msg {
+		src, err := db.LoadSchema(source)
+		if err != nil {
+			return schemaDiffMsg{err: err}
+		}
+		dst, err := db.LoadSchema(target)
+		if err != nil {
+			return schemaDiffMsg{err: err}
+		}
+		return schemaDiffMsg{diff: db.DiffSchemas(src, dst)}
+	}, a.inputSpinner.Tick)
+}
+
+func (a *App) handleSchemaDiffResult(msg schemaDiffMsg) (tea.Model, tea.Cmd) {
+	a.schemaDiffing = false
+	a.schemaDiff = msg.diff
+	a.schemaDiffErr = msg.err
+	a.schemaDiffScroll = 0
+	a.schemaExportPath = ""
+	a.showingSchemaDiff = true
+	return a, nil
+}
+
+// closeSchemaDiff dismisses the diff and closes a temporary target connection
+func (a *App) closeSchemaDiff() {
+	if a.schemaTargetOwned && a.schemaTarget.DB != nil {
+		a.schemaTarget.DB.Close()
+	}
+	a.schemaTarget = db.TableSide{}
+	a.schemaTargetOwned = false
+	a.schemaTargetDBs = nil
+	a.schemaDiff = nil
+	a.schemaPickingConn = false
+	a.schemaPickingDB = false
+	a.showingSchemaDiff = false
+}
+
+func (a *App) schemaDiffTargetLabel() string {
+	if a.schemaTarget.DBName == "" {
+		return a.schemaTargetName
+	}
+	return a.schemaTargetName + "/" + a.schemaTarget.DBName
+}
+
+func (a *App) updateSchemaDiffPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n := len(a.schemaDiffTargets())
+	if a.schemaPickingDB {
+		n = len(a.schemaTargetDBs)
+	}
+
+	switch msg.String() {
+	case "esc":
+		a.closeSchemaDiff()
+	case "j", "down":
+		a.schemaCursor = moveCursor(a.schemaCursor, 1, n)
+	case "k", "up":
+		a.schemaCursor = moveCursor(a.schemaCursor, -1, n)
+	case "enter":
+		if n == 0 {
+			return a, nil
+		}
+		if a.schemaPickingConn {
+			return a, a.pickSchemaDiffConn(a.schemaDiffTargets()[a.schemaCursor])
+		}
+		a.schemaPickingDB = false
+		a.schemaTarget.DBName = a.schemaTargetDBs[a.schemaCursor]
+		return a, a.runSchemaDiff()
+	}
+	return a, nil
+}
+
+func (a *App) updateSchemaDiff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.closeSchemaDiff()
+	case "j", "down":
+		a.schemaDiffScroll++
+	case "k", "up":
+		a.schemaDiffScroll = max(0, a.schemaDiffScroll-1)
+	case "e", "g":
+		if a.schemaDiff == nil {
+			return a, nil
+		}
+		prefix, ext, content := "schema_diff", "txt", a.schemaDiff.Report()
+		if msg.String() == "g" {
+			prefix, ext, content = "schema_migration", "sql", a.schemaDiff.MigrationScript(a.dbType)
+		}
+		path, err := writeExport(prefix, ext, content)
+		if err != nil {
+			a.schemaDiffErr = err
+			return a, nil
+		}
+		clipboard.WriteAll(content)
+		a.schemaExportPath = path
+	}
+	return a, nil
+}
+
+func (a *App) viewSchemaDiffPicker() string {
+	var b strings.Builder
+	source := a.selectedDatabase
+	if source == "" {
+		source = a.currentConnName()
+	}
+
+	var items []string
+	if a.schemaPickingDB {
+		b.WriteString(selectedStyle.Render("Compare schema of " + source + " with database on " + a.schemaTargetName + ":"))
+		items = a.schemaTargetDBs
+	} else {
+		b.WriteString(selectedStyle.Render("Compare schema of " + source + " with:"))
+		for _, conn := range a.schemaDiffTargets() {
+			label := conn.Name
+			if conn.Name == a.currentConnName() {
+				label += dimStyle.Render(" (this connection)")
+			}
+			items = append(items, label)
+		}
+	}
+	b.WriteString("\n\n")
+
+	if len(items) == 0 {
+		b.WriteString(dimStyle.Render("Nothing to compare with"))
+	}
+	for i, item := range items {
+		if i == a.schemaCursor {
+			b.WriteString("> " + selectedStyle.Render(item))
+		} else {
+			b.WriteString("  " + item)
+		}
+		b.WriteString("\n")
+	}
+
+	return a.renderFrame(b.String(), "j/k: navigate • enter: select • esc: cancel")
+}
+
+func (a *App) viewSchemaDiff() string {
+	var b strings.Builder
+	if a.schemaDiffing {
+		b.WriteString(a.inputSpinner.View() + " Comparing schema with " + a.schemaDiffTargetLabel() + "...")
+		return a.renderFrame(b.String(), "please wait")
+	}
+
+	b.WriteString(selectedStyle.Render("Schema diff"))
+	b.WriteString(dimStyle.Render(" (this database → " + a.schemaDiffTargetLabel() + ")"))
+	b.WriteString("\n\n")
+
+	if a.schemaDiffErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.schemaDiffErr.Error()))
+		if a.schemaDiff == nil {
+			return a.renderFrame(b.String(), "esc: close")
+		}
+		b.WriteString("\n\n")
+	}
+
+	d := a.schemaDiff
+	b.WriteString(errorStyle.Render(fmt.Sprintf("%d missing", len(d.MissingTables))))
+	b.WriteString("  ")
+	b.WriteString(editingStyle.Render(fmt.Sprintf("%d extra", len(d.ExtraTables))))
+	b.WriteString("  ")
+	b.WriteString(editingStyle.Render(fmt.Sprintf("%d different", len(d.Tables))))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("+ missing from target • - only in target • ~ different"))
+	b.WriteString("\n\n")
+
+	lines := strings.Split(strings.TrimRight(d.Report(), "\n"), "\n")
+	height := max(1, a.viewport.Height-4)
+	a.schemaDiffScroll = min(a.schemaDiffScroll, max(0, len(lines)-height))
+	end := min(len(lines), a.schemaDiffScroll+height)
+	for _, line := range lines[a.schemaDiffScroll:end] {
+		switch trimmed := strings.TrimLeft(line, " "); {
+		case strings.HasPrefix(trimmed, "+"):
+			line = selectedStyle.Render(line)
+		case strings.HasPrefix(trimmed, "-"):
+			line = errorStyle.Render(line)
+		case strings.HasPrefix(trimmed, "~"):
+			line = editingStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if a.schemaExportPath != "" {
+		b.WriteString("\n")
+		b.WriteString(selectedStyle.Render("Copied and saved to " + a.schemaExportPath))
+	}
+
+	controls := "j/k: scroll • e: export report • g: generate migration • esc: close"
+	return a.renderFrame(b.String(), controls)
+}
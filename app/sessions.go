@@ -0,0 +1,266 @@
+// sessions.go manages several simultaneously open connections. Each session
+// keeps its own *sql.DB, selected database and result tabs. The active
+// session's state lives directly on App and is saved back into the session
+// when the user switches to another one via the switcher overlay.
+package app
+
+import (
+	"database/sql"
+	"dbsurf/config"
+	"dbsurf/db"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sessionColors are assigned to connections by name so a connection keeps
+// the same colour across runs
+var sessionColors = []lipgloss.Color{"6", "5", "4", "2", "3", "13", "14", "12"}
+
+type session struct {
+	conn             config.Connection
+	db               *sql.DB
+	dbType           string
+	selectedDatabase string
+	databases        []string
+	mode             mode
+	tabs             []resultTab
+	activeTab        int
+}
+
+func sessionColor(name string) lipgloss.Color {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return sessionColors[h.Sum32()%uint32(len(sessionColors))]
+}
+
+func (s session) style() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(sessionColor(s.conn.Name)).Bold(true)
+}
+
+// findSession returns the index of the open session for conn, or -1
+func (a *App) findSession(conn config.Connection) int {
+	for i, s := range a.sessions {
+		if s.conn.Is(conn) {
+			return i
+		}
+	}
+	return -1
+}
+
+// saveSession copies the live App state back into the active session
+func (a *App) saveSession() {
+	if a.activeSession < 0 || a.activeSession >= len(a.sessions) {
+		return
+	}
+	a.saveActiveTab()
+	s := &a.sessions[a.activeSession]
+	s.db = a.db
+	s.dbType = a.dbType
+	s.selectedDatabase = a.selectedDatabase
+	s.databases = a.databases
+	s.tabs = a.tabs
+	s.activeTab = a.activeTab
+	switch a.mode {
+	case modeConnected, modeQuery:
+		s.mode = a.mode
+	case modeTableList:
+		s.mode = modeQuery
+	case modeActivity:
+		s.mode = modeConnected
+	}
+}
+
+// loadSession makes session i active, restoring its connection and tabs
+func (a *App) loadSession(i int) tea.Cmd {
+	s := a.sessions[i]
+	a.activeSession = i
+	a.db = s.db
+	a.dbErr = nil
+	a.dbType = s.dbType
+	a.selectedDatabase = s.selectedDatabase
+	a.databases = s.databases
+	a.filteredDatabases = s.databases
+	a.dbCursor = 0
+	a.dbSearching = false
+	a.dbSearchInput.Reset()
+	a.tabs = s.tabs
+	if len(a.tabs) == 0 {
+		a.resetTabs()
+	}
+	a.loadTab(min(s.activeTab, len(a.tabs)-1))
+	a.mode = s.mode
+	if a.mode == modeQuery && a.queryFocused {
+		a.queryInput.Focus()
+		return textinput.Blink
+	}
+	return nil
+}
+
+// openSession connects to conn, or switches to its session if already open
+func (a *App) openSession(conn config.Connection) tea.Cmd {
+	a.saveSession()
+	if i := a.findSession(conn); i != -1 {
+		return a.loadSession(i)
+	}
+
+	// Start with a fresh set of tabs and no result or history, so the new
+	// session doesn't share state with the one just saved
+	a.activeSession = -1
+	a.resetTabs()
+	a.loadTab(0)
+	a.selectedDatabase = ""
+	a.db = nil
+	a.dbType = conn.DBType
+	a.db, a.dbErr = a.connectSaved(conn)
+	a.databases = nil
+	if a.dbErr == nil {
+		a.sessions = append(a.sessions, session{conn: conn})
+		a.activeSession = len(a.sessions) - 1
+		a.databases, _ = db.ListDatabases(a.db, a.dbType)
+	}
+	a.filteredDatabases = a.databases
+	a.dbCursor = 0
+	a.dbSwitchErr = nil
+	if a.dbType == "postgres" && a.dbErr == nil {
+		// Start on the database named in the connection string
+		current, _ := db.CurrentDatabase(a.db, a.dbType)
+		a.selectedDatabase = current
+		a.dbCursor = max(0, slices.Index(a.databases, current))
+	}
+	a.dbSearching = false
+	a.dbSearchInput.Reset()
+	a.mode = modeConnected
+	return nil
+}
+
+// closeSession disconnects session i and activates a neighbouring session
+func (a *App) closeSession(i int) {
+	a.saveSession()
+	if a.sessions[i].db != nil {
+		a.sessions[i].db.Close()
+	}
+	a.sessions = append(a.sessions[:i], a.sessions[i+1:]...)
+
+	if len(a.sessions) == 0 {
+		a.activeSession = -1
+		a.db = nil
+		a.mode = modeList
+		return
+	}
+	if i == a.activeSession {
+		a.loadSession(min(i, len(a.sessions)-1))
+	} else if i < a.activeSession {
+		a.activeSession--
+	}
+}
+
+// closeAllSessions disconnects every open session, e.g. on quit
+func (a *App) closeAllSessions() {
+	a.saveSession()
+	for _, s := range a.sessions {
+		if s.db != nil {
+			s.db.Close()
+		}
+	}
+	a.sessions = nil
+	a.activeSession = -1
+	a.db = nil
+}
+
+func (a *App) currentConnName() string {
+	if a.activeSession >= 0 && a.activeSession < len(a.sessions) {
+		return a.sessions[a.activeSession].conn.Name
+	}
+	if a.cursor < len(a.config.Connections) {
+		return a.config.Connections[a.cursor].Name
+	}
+	return ""
+}
+
+func (a *App) startSessionSwitcher() {
+	a.saveSession()
+	a.sessionCursor = max(a.activeSession, 0)
+	a.showingSessions = true
+}
+
+func (a *App) updateSessionSwitcher(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+s":
+		a.showingSessions = false
+	case "j", "down":
+		a.sessionCursor = moveCursor(a.sessionCursor, 1, len(a.sessions))
+	case "k", "up":
+		a.sessionCursor = moveCursor(a.sessionCursor, -1, len(a.sessions))
+	case "x":
+		if len(a.sessions) > 0 {
+			a.closeSession(a.sessionCursor)
+			a.sessionCursor = moveCursor(a.sessionCursor, 0, len(a.sessions))
+			if len(a.sessions) == 0 {
+				a.showingSessions = false
+			}
+		}
+	case "enter":
+		a.showingSessions = false
+		if a.sessionCursor < len(a.sessions) {
+			return a, a.loadSession(a.sessionCursor)
+		}
+	}
+	return a, nil
+}
+
+func (a *App) viewSessionSwitcher() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Open Connections"))
+	b.WriteString("\n\n")
+
+	for i, s := range a.sessions {
+		prefix := "  "
+		if i == a.sessionCursor {
+			prefix = "> "
+		}
+		where := s.selectedDatabase
+		if where == "" {
+			where = "-"
+		}
+		b.WriteString(prefix)
+		b.WriteString(s.style().Render("● " + s.conn.Name))
+		detail := fmt.Sprintf(" (%s) %s", s.dbType, where)
+		if i == a.activeSession {
+			detail += " [active]"
+		}
+		if i == a.sessionCursor {
+			b.WriteString(selectedStyle.Render(detail))
+		} else {
+			b.WriteString(dimStyle.Render(detail))
+		}
+		b.WriteString("\n")
+	}
+
+	controls := "j/k: navigate • enter: switch • x: disconnect • esc: close"
+	return a.renderFrame(b.String(), controls)
+}
+
+// viewStatusLine shows which connection is active, coloured per connection
+func (a *App) viewStatusLine() string {
+	if a.activeSession < 0 || a.activeSession >= len(a.sessions) {
+		return ""
+	}
+	s := a.sessions[a.activeSession]
+	line := s.style().Render("● "+s.conn.Name) + dimStyle.Render(" ("+a.dbType+")")
+	if a.selectedDatabase != "" {
+		line += dimStyle.Render(" / ") + a.selectedDatabase
+	}
+	if badges := safetyBadges(s.conn); badges != "" {
+		line += "  " + badges
+	}
+	if len(a.sessions) > 1 {
+		line += dimStyle.Render(fmt.Sprintf("  •  %d open (ctrl+s)", len(a.sessions)))
+	}
+	return line
+}
@@ -0,0 +1,252 @@
+// table_compare.go compares a table's data between the current connection and
+// another saved connection. The user picks a table in the table list, then a
+// target connection; the comparison runs in the background and shows a
+// summary with drill-down into missing, extra and differing rows. A script
+// that would reconcile the target can be generated from the result.
+package app
+
+import (
+	"dbsurf/config"
+	"dbsurf/db"
+	"fmt"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const maxCompareDiffs = 1000
+
+type tableCompareMsg struct {
+	result *db.TableComparison
+	err    error
+}
+
+// compareTargets lists the saved connections other than the active one
+func (a *App) compareTargets() []config.Connection {
+	var targets []config.Connection
+	for _, conn := range a.config.Connections {
+		if a.activeSession >= 0 && conn.Is(a.sessions[a.activeSession].conn) {
+			continue
+		}
+		targets = append(targets, conn)
+	}
+	return targets
+}
+
+func (a *App) startTableCompare(tableName string) {
+	a.compareTable = tableName
+	a.compareConnCursor = 0
+	a.compareErr = nil
+	a.comparePicking = true
+}
+
+// runTableCompare compares the table against conn in the background. An open
+// session for conn is reused; otherwise a temporary connection is opened. The
+// target database defaults to the current one for engines that have several.
+func (a *App) runTableCompare(conn config.Connection) tea.Cmd {
+	source := db.TableSide{DB: a.db, DBName: a.selectedDatabase, DBType: a.dbType}
+	target := db.TableSide{DBName: a.selectedDatabase, DBType: conn.DBType}
+	if target.DBType == "postgres" {
+		target.DBName = ""
+	}
+	if i := a.findSession(conn); i != -1 {
+		target.DB = a.sessions[i].db
+		if a.sessions[i].selectedDatabase != "" {
+			target.DBName = a.sessions[i].selectedDatabase
+		}
+	}
+	a.compareTarget = target
+	a.compareTargetName = conn.Name
+	tableName := a.compareTable
+	// Resolve the password here so the secret backend is not used off the
+	// UI goroutine
+	var opts db.ConnectOptions
+	var connErr error
+	if target.DB == nil {
+		opts, connErr = a.connectOptions(conn)
+	}
+
+	return func() tea.Msg {
+		if target.DB == nil {
+			if connErr != nil {
+				return tableCompareMsg{err: connErr}
+			}
+			targetDB, err := db.ConnectWith(conn.ConnString, opts)
+			if err != nil {
+				return tableCompareMsg{err: err}
+			}
+			defer targetDB.Close()
+			target.DB = targetDB
+		}
+		result, err := db.CompareTable(source, target, tableName, maxCompareDiffs)
+		return tableCompareMsg{result: result, err: err}
+	}
+}
+
+func (a *App) handleTableCompareResult(msg tableCompareMsg) (tea.Model, tea.Cmd) {
+	a.comparing = false
+	a.compareResult = msg.result
+	a.compareErr = msg.err
+	a.compareCursor = 0
+	a.compareExportPath = ""
+	a.showingCompare = true
+	return a, nil
+}
+
+func (a *App) updateComparePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	targets := a.compareTargets()
+	switch msg.String() {
+	case "esc":
+		a.comparePicking = false
+	case "j", "down":
+		a.compareConnCursor = moveCursor(a.compareConnCursor, 1, len(targets))
+	case "k", "up":
+		a.compareConnCursor = moveCursor(a.compareConnCursor, -1, len(targets))
+	case "enter":
+		if len(targets) > 0 {
+			a.comparePicking = false
+			a.comparing = true
+			return a, tea.Batch(a.runTableCompare(targets[a.compareConnCursor]), a.inputSpinner.Tick)
+		}
+	}
+	return a, nil
+}
+
+func (a *App) updateTableCompare(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.showingCompare = false
+		a.compareResult = nil
+	case "j", "down":
+		if a.compareResult != nil {
+			a.compareCursor = moveCursor(a.compareCursor, 1, len(a.compareResult.Diffs))
+		}
+	case "k", "up":
+		if a.compareResult != nil {
+			a.compareCursor = moveCursor(a.compareCursor, -1, len(a.compareResult.Diffs))
+		}
+	case "g":
+		if a.compareResult != nil && len(a.compareResult.Diffs) > 0 {
+			script := db.ReconcileScript(a.compareResult, a.compareTarget)
+			path, err := writeExport("reconcile_"+db.CleanTableName(a.compareTable, a.dbType), "sql", script)
+			if err != nil {
+				a.compareErr = err
+				return a, nil
+			}
+			clipboard.WriteAll(script)
+			a.compareExportPath = path
+		}
+	}
+	return a, nil
+}
+
+func (a *App) viewComparePicker() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Compare " + a.compareTable + " with:"))
+	b.WriteString("\n\n")
+
+	targets := a.compareTargets()
+	if len(targets) == 0 {
+		b.WriteString(dimStyle.Render("No other saved connections"))
+	}
+	for i, conn := range targets {
+		line := fmt.Sprintf("%s (%s)", conn.Name, conn.DBType)
+		if i == a.compareConnCursor {
+			b.WriteString("> " + selectedStyle.Render(line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	controls := "j/k: navigate • enter: compare • esc: cancel"
+	return a.renderFrame(b.String(), controls)
+}
+
+func (a *App) viewTableCompare() string {
+	var b strings.Builder
+	if a.comparing {
+		b.WriteString(a.inputSpinner.View() + " Comparing " + a.compareTable + " with " + a.compareTargetName + "...")
+		return a.renderFrame(b.String(), "please wait")
+	}
+
+	b.WriteString(selectedStyle.Render("Compare " + a.compareTable))
+	b.WriteString(dimStyle.Render(" (this connection → " + a.compareTargetName + ")"))
+	b.WriteString("\n\n")
+
+	if a.compareErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.compareErr.Error()))
+		if a.compareResult == nil {
+			return a.renderFrame(b.String(), "esc: close")
+		}
+		b.WriteString("\n\n")
+	}
+
+	cmp := a.compareResult
+	b.WriteString(selectedStyle.Render(fmt.Sprintf("%d matching", cmp.Matching)))
+	b.WriteString("  ")
+	b.WriteString(errorStyle.Render(fmt.Sprintf("%d missing", cmp.Missing)))
+	b.WriteString("  ")
+	b.WriteString(editingStyle.Render(fmt.Sprintf("%d extra", cmp.Extra)))
+	b.WriteString("  ")
+	b.WriteString(editingStyle.Render(fmt.Sprintf("%d different", cmp.Different)))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("missing: only in this connection • extra: only in " + a.compareTargetName))
+	b.WriteString("\n\n")
+
+	if len(cmp.Diffs) == 0 {
+		b.WriteString(dimStyle.Render("Tables match"))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+
+	var lines []string
+	for i, d := range cmp.Diffs {
+		prefix := "  "
+		if i == a.compareCursor {
+			prefix = "> "
+		}
+		line := fmt.Sprintf("%-9s %s", d.Kind, compareKeyLabel(cmp.PKColumns, d.Key))
+		if i == a.compareCursor {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, prefix+line)
+	}
+	listHeight := min(len(lines), 8)
+	start := max(0, min(a.compareCursor-listHeight+1, len(lines)-listHeight))
+	b.WriteString(strings.Join(lines[start:start+listHeight], "\n"))
+	b.WriteString("\n\n")
+
+	d := cmp.Diffs[a.compareCursor]
+	changed := make(map[int]bool)
+	for _, idx := range d.Changed {
+		changed[idx] = true
+	}
+	for i, col := range cmp.Columns {
+		switch {
+		case d.Kind == db.RowExtra:
+			b.WriteString(fmt.Sprintf("  %s: %s\n", editingStyle.Render(col), valueStyle.Render(d.Target[i])))
+		case changed[i]:
+			b.WriteString(fmt.Sprintf("  %s: %s → %s\n", editingStyle.Render(col),
+				valueStyle.Render(d.Source[i]), changedCellStyle.Render(d.Target[i])))
+		default:
+			b.WriteString(fmt.Sprintf("  %s: %s\n", selectedStyle.Render(col), valueStyle.Render(d.Source[i])))
+		}
+	}
+
+	if a.compareExportPath != "" {
+		b.WriteString("\n")
+		b.WriteString(selectedStyle.Render("Script copied and saved to " + a.compareExportPath))
+	}
+
+	controls := "j/k: navigate • g: generate reconcile script • esc: close"
+	return a.renderFrame(b.String(), controls)
+}
+
+func compareKeyLabel(pk, key []string) string {
+	parts := make([]string, len(key))
+	for i, v := range key {
+		parts[i] = pk[i] + "=" + v
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,81 @@
+// table_ddl.go shows the CREATE TABLE statement for a table, or the
+// definition source of a view, routine, trigger or sequence. It is opened
+// from the table list or the column info overlay, and the DDL can be copied
+// to the clipboard or exported to a file.
+package app
+
+import (
+	"dbsurf/db"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (a *App) showTableDDL(tableName string) {
+	a.ddlTable = tableName
+	a.ddlText, a.ddlErr = db.GetTableDDL(a.db, a.selectedDatabase, tableName, a.dbType)
+	a.ddlScroll = 0
+	a.ddlStatus = ""
+	a.showingDDL = true
+}
+
+func (a *App) showObjectDefinition(obj db.DBObject) {
+	a.ddlTable = obj.Name
+	a.ddlText, a.ddlErr = db.GetObjectDefinition(a.db, a.selectedDatabase, obj, a.dbType)
+	a.ddlScroll = 0
+	a.ddlStatus = ""
+	a.showingDDL = true
+}
+
+func (a *App) updateTableDDL(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		a.showingDDL = false
+	case "j", "down":
+		a.ddlScroll++
+	case "k", "up":
+		a.ddlScroll = max(0, a.ddlScroll-1)
+	case "y", "ctrl+c":
+		if a.ddlErr == nil {
+			clipboard.WriteAll(a.ddlText)
+			a.ddlStatus = "Copied to clipboard"
+		}
+	case "e":
+		if a.ddlErr == nil {
+			path, err := writeExport("ddl_"+db.CleanTableName(a.ddlTable, a.dbType), "sql", a.ddlText+"\n")
+			if err != nil {
+				a.ddlErr = err
+				return a, nil
+			}
+			a.ddlStatus = "Saved to " + path
+		}
+	}
+	return a, nil
+}
+
+func (a *App) viewTableDDL() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("DDL: " + a.ddlTable))
+	b.WriteString("\n\n")
+
+	if a.ddlErr != nil {
+		b.WriteString(errorStyle.Render("Error: " + a.ddlErr.Error()))
+		return a.renderFrame(b.String(), "esc: close")
+	}
+
+	lines := strings.Split(a.ddlText, "\n")
+	height := max(1, a.viewport.Height-2)
+	a.ddlScroll = min(a.ddlScroll, max(0, len(lines)-height))
+	end := min(len(lines), a.ddlScroll+height)
+	for _, line := range lines[a.ddlScroll:end] {
+		b.WriteString(valueStyle.Render(line) + "\n")
+	}
+
+	if a.ddlStatus != "" {
+		b.WriteString("\n")
+		b.WriteString(selectedStyle.Render(a.ddlStatus))
+	}
+
+	return a.renderFrame(b.String(), "j/k: scroll • y: copy • e: export • esc: close")
+}
@@ -0,0 +1,120 @@
+// table_stats.go adds optional size and activity columns to the table list:
+// estimated rows, total/data/index size and last analyzed/updated times. The
+// list can be sorted by any of them to spot the biggest tables.
+package app
+
+import (
+	"cmp"
+	"dbsurf/db"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// tableSortKeys are the orders cycled through with "o" in the table list
+var tableSortKeys = []string{"name", "rows", "total size", "data size", "index size", "last analyzed", "last updated"}
+
+// toggleTableStats shows or hides the metadata columns, loading them on first use
+func (a *App) toggleTableStats() error {
+	if a.showingTableStats {
+		a.showingTableStats = false
+		return nil
+	}
+	if a.tableStats == nil {
+		stats, err := db.GetTableStats(a.db, a.selectedDatabase, a.dbType)
+		if err != nil {
+			return err
+		}
+		a.tableStats = stats
+	}
+	a.showingTableStats = true
+	return nil
+}
+
+// cycleTableSort moves to the next sort order. Sorting by anything other than
+// name shows the metadata columns.
+func (a *App) cycleTableSort() error {
+	a.tableSortKey = (a.tableSortKey + 1) % len(tableSortKeys)
+	if a.tableSortKey != 0 && !a.showingTableStats {
+		if err := a.toggleTableStats(); err != nil {
+			a.tableSortKey = 0
+			return err
+		}
+	}
+	a.sortTables()
+	a.filterTables()
+	a.tableCursor = 0
+	return nil
+}
+
+// sortTables orders a.tables within each kind group by the current sort key.
+// Sizes, counts and times sort largest/most recent first.
+func (a *App) sortTables() {
+	key := tableSortKeys[a.tableSortKey]
+	slices.SortStableFunc(a.tables, func(x, y db.DBObject) int {
+		if d := slices.Index(db.ObjectKinds, x.Kind) - slices.Index(db.ObjectKinds, y.Kind); d != 0 {
+			return d
+		}
+		sx, sy := a.tableStats[x.Name], a.tableStats[y.Name]
+		var c int
+		switch key {
+		case "rows":
+			c = cmp.Compare(sy.Rows, sx.Rows)
+		case "total size":
+			c = cmp.Compare(sy.TotalBytes, sx.TotalBytes)
+		case "data size":
+			c = cmp.Compare(sy.DataBytes, sx.DataBytes)
+		case "index size":
+			c = cmp.Compare(sy.IndexBytes, sx.IndexBytes)
+		case "last analyzed":
+			c = strings.Compare(sy.LastAnalyzed, sx.LastAnalyzed)
+		case "last updated":
+			c = strings.Compare(sy.LastUpdated, sx.LastUpdated)
+		}
+		if c != 0 {
+			return c
+		}
+		return strings.Compare(x.Name, y.Name)
+	})
+}
+
+// tableStatsHeader returns the column headings aligned with tableStatsColumns
+func tableStatsHeader(nameWidth int) string {
+	return fmt.Sprintf("  %-*s %10s %9s %9s %9s  %-16s %-16s", nameWidth, "",
+		"Rows", "Total", "Data", "Index", "Analyzed", "Updated")
+}
+
+// tableStatsColumns renders the metadata columns for a table, or nothing for
+// objects without statistics
+func (a *App) tableStatsColumns(obj db.DBObject) string {
+	s, ok := a.tableStats[obj.Name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" %10s %9s %9s %9s  %-16s %-16s", formatCount(s.Rows),
+		formatBytes(s.TotalBytes), formatBytes(s.DataBytes), formatBytes(s.IndexBytes),
+		cmp.Or(s.LastAnalyzed, "-"), cmp.Or(s.LastUpdated, "-"))
+}
+
+// formatBytes renders a size with a binary unit, e.g. 1.5 MB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatCount renders a row count with thousands separators
+func formatCount(n int64) string {
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
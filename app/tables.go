@@ -1,61 +1,168 @@
-// tables.go handles the table list mode for browsing database tables.
-// It supports filtering tables and viewing column info for selected tables.
+// tables.go handles the table list mode for browsing database objects.
+// Tables, views, routines, triggers and sequences are grouped by kind. It
+// supports filtering, viewing column info and definitions, selecting from
+// tables and views, and executing procedures.
 package app
 
 import (
 	"dbsurf/db"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+var kindHeadings = map[string]string{
+	db.KindTable:            "Tables",
+	db.KindView:             "Views",
+	db.KindMaterializedView: "Materialized Views",
+	db.KindFunction:         "Functions",
+	db.KindProcedure:        "Procedures",
+	db.KindTrigger:          "Triggers",
+	db.KindSequence:         "Sequences",
+}
+
 func (a *App) filterTables() {
-	a.filteredTables = filterStrings(a.tables, a.tableSearchInput.Value())
+	q := strings.ToLower(a.tableSearchInput.Value())
+	if q == "" && a.tableSchema == "" {
+		a.filteredTables = a.tables
+		return
+	}
+	a.filteredTables = nil
+	for _, obj := range a.tables {
+		if schema, _ := db.SplitSchemaTable(obj.Name, a.dbType); a.tableSchema != "" && schema != a.tableSchema {
+			continue
+		}
+		if strings.Contains(strings.ToLower(obj.Name), q) {
+			a.filteredTables = append(a.filteredTables, obj)
+		}
+	}
 }
 
-func (a *App) updateTableList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	if a.showingColumnInfo {
-		if a.columnInfoSearching {
-			switch msg.String() {
-			case "esc":
-				a.columnInfoSearching = false
-				a.columnInfoSearchInput.Blur()
-				return a, nil
-			case "enter":
-				a.columnInfoSearching = false
-				a.columnInfoSearchInput.Blur()
-				return a, nil
-			}
-			var cmd tea.Cmd
-			a.columnInfoSearchInput, cmd = a.columnInfoSearchInput.Update(msg)
-			a.columnInfoFilter = a.columnInfoSearchInput.Value()
-			a.filterAndRebuildColumnInfo()
-			return a, cmd
+// startSchemaList shows the schemas of the current database so the table
+// list can be narrowed to one. Engines without schemas do nothing.
+func (a *App) startSchemaList() {
+	schemas, err := db.ListSchemas(a.db, a.selectedDatabase, a.dbType)
+	if err != nil || len(schemas) == 0 {
+		return
+	}
+	a.tableSchemas = schemas
+	a.schemaListCursor = max(0, slices.Index(schemas, a.tableSchema)+1)
+	a.schemaListing = true
+}
+
+func (a *App) updateSchemaList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The first entry is "all schemas"
+	n := len(a.tableSchemas) + 1
+	switch msg.String() {
+	case "esc":
+		a.schemaListing = false
+	case "j", "down":
+		a.schemaListCursor = moveCursor(a.schemaListCursor, 1, n)
+	case "k", "up":
+		a.schemaListCursor = moveCursor(a.schemaListCursor, -1, n)
+	case "enter":
+		a.tableSchema = ""
+		if a.schemaListCursor > 0 {
+			a.tableSchema = a.tableSchemas[a.schemaListCursor-1]
 		}
+		a.schemaListing = false
+		a.filterTables()
+		a.tableCursor = 0
+	}
+	return a, nil
+}
 
-		switch msg.String() {
-		case "esc":
-			if a.columnInfoFilter != "" {
-				a.columnInfoFilter = ""
-				a.columnInfoSearchInput.SetValue("")
-				a.filterAndRebuildColumnInfo()
-				return a, nil
-			}
-			a.showingColumnInfo = false
-			return a, nil
-		case "?", "q":
-			a.showingColumnInfo = false
-			return a, nil
-		case "/":
-			a.columnInfoSearching = true
-			a.columnInfoSearchInput.Focus()
-			return a, textinput.Blink
+func (a *App) viewSchemaList() string {
+	var b strings.Builder
+	b.WriteString(selectedStyle.Render("Schemas in " + a.selectedDatabase))
+	b.WriteString("\n\n")
+	for i, schema := range append([]string{"All schemas"}, a.tableSchemas...) {
+		if i == a.schemaListCursor {
+			b.WriteString("> " + selectedStyle.Render(schema))
+		} else {
+			b.WriteString("  " + schema)
 		}
-		var cmd tea.Cmd
-		a.columnInfoTable, cmd = a.columnInfoTable.Update(msg)
-		return a, cmd
+		b.WriteString("\n")
+	}
+	return a.renderFrame(b.String(), "j/k: navigate • enter: select • esc: cancel")
+}
+
+// loadTableList lists the tables and other objects of the current database
+// and switches to the table list
+func (a *App) loadTableList() error {
+	tables, err := db.ListTables(a.db, a.selectedDatabase, a.dbType)
+	if err != nil {
+		return err
+	}
+	objects, err := db.ListObjects(a.db, a.selectedDatabase, a.dbType)
+	if err != nil {
+		return err
+	}
+
+	a.tables = make([]db.DBObject, 0, len(tables)+len(objects))
+	for _, name := range tables {
+		a.tables = append(a.tables, db.DBObject{Name: name, Kind: db.KindTable})
+	}
+	a.tables = append(a.tables, objects...)
+	a.tableStats = nil
+	a.showingTableStats = false
+	a.tableSortKey = 0
+	a.tableStatsErr = nil
+	a.filteredTables = a.tables
+	a.tableSchema = ""
+	a.tableCursor = 0
+	a.tableSearchInput.Reset()
+	a.tableSearching = false
+	a.mode = modeTableList
+	return nil
+}
+
+func (a *App) updateTableList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if a.comparePicking {
+		return a.updateComparePicker(msg)
+	}
+
+	if a.comparing {
+		return a, nil
+	}
+
+	if a.showingCompare {
+		return a.updateTableCompare(msg)
+	}
+
+	if a.schemaPickingConn || a.schemaPickingDB {
+		return a.updateSchemaDiffPicker(msg)
+	}
+
+	if a.schemaDiffing {
+		return a, nil
+	}
+
+	if a.showingSchemaDiff {
+		return a.updateSchemaDiff(msg)
+	}
+
+	if a.showingDDL {
+		return a.updateTableDDL(msg)
+	}
+
+	if a.showingExecForm {
+		return a.updateProcedureExec(msg)
+	}
+
+	if a.showingGrants {
+		return a.updateGrants(msg)
+	}
+
+	if a.schemaListing {
+		return a.updateSchemaList(msg)
+	}
+
+	if a.showingColumnInfo {
+		return a.updateColumnInfo(msg)
 	}
 
 	if a.tableSearching {
@@ -63,7 +170,7 @@ func (a *App) updateTableList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case "esc":
 			a.tableSearching = false
 			a.tableSearchInput.Reset()
-			a.filteredTables = a.tables
+			a.filterTables()
 			a.tableCursor = 0
 			return a, nil
 		case "enter":
@@ -83,54 +190,62 @@ func (a *App) updateTableList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "esc":
 		a.mode = modeQuery
 		return a, nil
+	case "ctrl+s":
+		a.startSessionSwitcher()
+		return a, nil
 	case "/":
 		a.tableSearching = true
 		a.tableSearchInput.Focus()
 		return a, textinput.Blink
+	case "c":
+		if len(a.filteredTables) > 0 && a.filteredTables[a.tableCursor].Kind == db.KindTable {
+			a.startTableCompare(a.filteredTables[a.tableCursor].Name)
+		}
+	case "g":
+		if len(a.filteredTables) > 0 && a.filteredTables[a.tableCursor].IsSelectable() {
+			a.startGrants(a.filteredTables[a.tableCursor].Name)
+		}
+	case "s":
+		a.startSchemaList()
+	case "m":
+		a.tableStatsErr = a.toggleTableStats()
+	case "o":
+		a.tableStatsErr = a.cycleTableSort()
+	case "D":
+		a.startSchemaDiff()
+	case "d":
+		if len(a.filteredTables) > 0 {
+			if obj := a.filteredTables[a.tableCursor]; obj.Kind == db.KindTable {
+				a.showTableDDL(obj.Name)
+			} else {
+				a.showObjectDefinition(obj)
+			}
+		}
 	case "j", "down":
 		a.tableCursor = moveCursor(a.tableCursor, 1, len(a.filteredTables))
 	case "k", "up":
 		a.tableCursor = moveCursor(a.tableCursor, -1, len(a.filteredTables))
 	case "?":
-		if len(a.filteredTables) > 0 {
-			tableName := a.filteredTables[a.tableCursor]
+		if len(a.filteredTables) > 0 && a.filteredTables[a.tableCursor].IsSelectable() {
+			tableName := a.filteredTables[a.tableCursor].Name
 			cleanName := db.CleanTableName(tableName, a.dbType)
-			info, err := db.GetColumnInfo(a.db, a.selectedDatabase, cleanName, a.dbType)
-			if err == nil && len(info) > 0 {
+			if a.openColumnInfo(cleanName, "") {
 				a.queryTableName = cleanName
-				a.columnInfoData = info
-				a.columnInfoFilter = ""
-				a.columnInfoSearchInput.SetValue("")
-				a.filteredColumnInfo = info
-				tableHeight := min(len(info), 15)
-				a.columnInfoTable = buildColumnInfoTable(info, tableHeight)
-				a.showingColumnInfo = true
 			}
 		}
 	case "enter":
 		if len(a.filteredTables) > 0 {
-			tableName := a.filteredTables[a.tableCursor]
-			selectQuery := fmt.Sprintf("SELECT * FROM %s", tableName)
-			fullQuery := db.PrependUseDatabase(selectQuery, a.selectedDatabase, a.dbType)
-			result, err := db.RunQuery(a.db, fullQuery)
-			if err != nil {
-				a.queryErr = err
-				a.queryResult = nil
-				a.filteredResultRows = nil
-			} else {
-				a.queryErr = nil
-				a.queryResult = result
-				a.queryInput.SetValue(selectQuery)
-				a.resultFilter = ""
-				a.resultSearchInput.SetValue("")
-				a.filterResults()
-				a.resultCursor = 0
-				a.fieldCursor = 0
-				cleanName := db.CleanTableName(tableName, a.dbType)
-				a.queryTableName = cleanName
-				a.queryPKColumns, _ = db.GetPrimaryKey(a.db, a.selectedDatabase, cleanName, a.dbType)
+			obj := a.filteredTables[a.tableCursor]
+			switch {
+			case obj.IsSelectable():
+				selectQuery := fmt.Sprintf("SELECT * FROM %s", obj.Name)
+				a.executeQuery(selectQuery, db.CleanTableName(obj.Name, a.dbType))
+				a.mode = modeQuery
+			case obj.Kind == db.KindProcedure:
+				return a, a.startProcedureExec(obj.Name)
+			default:
+				a.showObjectDefinition(obj)
 			}
-			a.mode = modeQuery
 			return a, nil
 		}
 	}
@@ -140,27 +255,54 @@ func (a *App) updateTableList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (a *App) viewTableList() string {
 	var content string
 
-	if a.showingColumnInfo {
-		content = selectedStyle.Render("Column Info: "+a.queryTableName) + "\n\n"
+	if a.comparePicking {
+		return a.viewComparePicker()
+	}
 
-		if a.columnInfoSearching {
-			content += inputLabelStyle.Render("Filter: ") + a.columnInfoSearchInput.View() + "\n\n"
-		} else if a.columnInfoFilter != "" {
-			content += dimStyle.Render("Filter: "+a.columnInfoFilter+" (esc to clear)") + "\n\n"
-		} else {
-			content += dimStyle.Render("Filter: press / to filter") + "\n\n"
-		}
+	if a.comparing || a.showingCompare {
+		return a.viewTableCompare()
+	}
 
-		if len(a.filteredColumnInfo) > 0 {
-			content += a.columnInfoTable.View()
-		} else {
-			content += dimStyle.Render("No columns match filter")
-		}
+	if a.schemaPickingConn || a.schemaPickingDB {
+		return a.viewSchemaDiffPicker()
+	}
 
-		return a.renderFrame(content, "j/k: navigate • /: filter • esc/?: close")
+	if a.schemaDiffing || a.showingSchemaDiff {
+		return a.viewSchemaDiff()
 	}
 
-	content = "Database: " + selectedStyle.Render(a.selectedDatabase) + "\n\n"
+	if a.showingDDL {
+		return a.viewTableDDL()
+	}
+
+	if a.showingExecForm {
+		return a.viewProcedureExec()
+	}
+
+	if a.showingGrants {
+		return a.viewGrants()
+	}
+
+	if a.schemaListing {
+		return a.viewSchemaList()
+	}
+
+	if a.showingColumnInfo {
+		return a.viewColumnInfo()
+	}
+
+	content = "Database: " + selectedStyle.Render(a.selectedDatabase)
+	if a.tableSchema != "" {
+		content += "  Schema: " + selectedStyle.Render(a.tableSchema)
+	}
+	if a.tableSortKey != 0 {
+		content += "  Sort: " + selectedStyle.Render(tableSortKeys[a.tableSortKey]+" ↓")
+	}
+	content += "\n\n"
+
+	if a.tableStatsErr != nil {
+		content += errorStyle.Render("Error: "+a.tableStatsErr.Error()) + "\n\n"
+	}
 
 	if a.tableSearching {
 		content += inputLabelStyle.Render("Search: ") + a.tableSearchInput.View() + "\n\n"
@@ -170,26 +312,49 @@ func (a *App) viewTableList() string {
 		content += dimStyle.Render("Search: press / to filter") + "\n\n"
 	}
 
-	content += "Tables:\n"
 	if len(a.filteredTables) > 0 {
+		nameWidth := 0
+		if a.showingTableStats {
+			for _, obj := range a.filteredTables {
+				nameWidth = max(nameWidth, len(obj.Name))
+			}
+		}
+
 		var lines []string
-		for i, tbl := range a.filteredTables {
+		cursorLine := 0
+		for i, obj := range a.filteredTables {
+			if i == 0 || obj.Kind != a.filteredTables[i-1].Kind {
+				if i > 0 {
+					lines = append(lines, "")
+				}
+				lines = append(lines, kindHeadings[obj.Kind]+":")
+				if a.showingTableStats && obj.Kind == db.KindTable {
+					lines = append(lines, dimStyle.Render(tableStatsHeader(nameWidth)))
+				}
+			}
 			prefix := "  "
-			line := tbl
+			line := obj.Name
+			if a.showingTableStats {
+				line = fmt.Sprintf("%-*s", nameWidth, obj.Name)
+			}
 			if i == a.tableCursor {
 				prefix = "> "
 				line = selectedStyle.Render(line)
+				cursorLine = len(lines)
+			}
+			if a.showingTableStats {
+				line += dimStyle.Render(a.tableStatsColumns(obj))
 			}
 			lines = append(lines, prefix+line)
 		}
 		a.viewport.SetContent(strings.Join(lines, "\n"))
-		a.syncViewportToCursor(a.tableCursor, len(a.filteredTables))
+		a.syncViewportToCursor(cursorLine, len(lines))
 		content += a.viewport.View()
 	} else {
 		content += dimStyle.Render("No tables found")
 	}
 
-	controls := "j/k: navigate • /: search • ?: cols • enter: select • esc: back • q: quit"
+	controls := "j/k: navigate • /: search • s: schema • m: sizes • o: sort • ?: cols • d: DDL/definition • g: grants • c: compare • D: schema diff • enter: select/execute • esc: back • q: quit"
 
 	return a.renderFrame(content, controls)
 }
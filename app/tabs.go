@@ -0,0 +1,200 @@
+// tabs.go manages multiple result tabs within a query session. Each tab owns
+// its own query text, result, filter, cursors and navigation history. The
+// active tab's state lives directly on App; it is saved into the tab when the
+// user switches away and restored when they switch back.
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const maxTabLabelLen = 16
+
+var (
+	activeTabStyle = lipgloss.NewStyle().
+			Foreground(ColorPrimary).
+			Bold(true).
+			Underline(true)
+
+	inactiveTabStyle = lipgloss.NewStyle().
+				Foreground(ColorMuted)
+)
+
+type resultTab struct {
+	name       string
+	state      navEntry
+	queryErr   error
+	navBack    []navEntry
+	navForward []navEntry
+}
+
+// label returns the tab's custom name, falling back to the table or query it shows
+func (t resultTab) label() string {
+	name := t.name
+	if name == "" {
+		name = t.state.label()
+	}
+	if name == "" {
+		name = "new"
+	}
+	return truncate(name, maxTabLabelLen)
+}
+
+// resetTabs discards all tabs and starts over with a single empty one
+func (a *App) resetTabs() {
+	a.tabs = []resultTab{{}}
+	a.activeTab = 0
+	a.resetNav()
+}
+
+func (a *App) saveActiveTab() {
+	if len(a.tabs) == 0 {
+		a.tabs = []resultTab{{}}
+		a.activeTab = 0
+	}
+	tab := &a.tabs[a.activeTab]
+	tab.state = a.currentNavEntry()
+	tab.queryErr = a.queryErr
+	tab.navBack = a.navBack
+	tab.navForward = a.navForward
+}
+
+func (a *App) loadTab(i int) {
+	a.activeTab = i
+	tab := a.tabs[i]
+	a.restoreNavEntry(tab.state)
+	a.queryErr = tab.queryErr
+	a.navBack = tab.navBack
+	a.navForward = tab.navForward
+	if len(a.filteredResultRows) == 0 {
+		a.queryFocused = true
+		a.queryInput.Focus()
+	}
+}
+
+// switchTab activates the tab at index i, saving the current one first
+func (a *App) switchTab(i int) {
+	a.saveActiveTab()
+	if i < 0 || i >= len(a.tabs) || i == a.activeTab {
+		return
+	}
+	a.loadTab(i)
+}
+
+// newTab opens an empty tab after the current one and focuses the query input
+func (a *App) newTab() tea.Cmd {
+	a.saveActiveTab()
+	i := a.activeTab + 1
+	a.tabs = append(a.tabs[:i], append([]resultTab{{}}, a.tabs[i:]...)...)
+	a.loadTab(i)
+	a.queryFocused = true
+	a.queryInput.Focus()
+	return textinput.Blink
+}
+
+// closeTab removes the active tab. Closing the last tab just clears it.
+func (a *App) closeTab() {
+	if len(a.tabs) <= 1 {
+		a.resetTabs()
+		a.loadTab(0)
+		return
+	}
+	a.tabs = append(a.tabs[:a.activeTab], a.tabs[a.activeTab+1:]...)
+	a.loadTab(min(a.activeTab, len(a.tabs)-1))
+}
+
+func (a *App) startTabRename() tea.Cmd {
+	a.saveActiveTab()
+	a.tabRenaming = true
+	a.tabRenameInput.SetValue(a.tabs[a.activeTab].name)
+	a.tabRenameInput.Focus()
+	a.queryInput.Blur()
+	return textinput.Blink
+}
+
+func (a *App) updateTabRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.tabRenaming = false
+		a.tabRenameInput.Blur()
+		return a, nil
+	case "enter":
+		a.tabs[a.activeTab].name = strings.TrimSpace(a.tabRenameInput.Value())
+		a.tabRenaming = false
+		a.tabRenameInput.Blur()
+		if a.queryFocused {
+			a.queryInput.Focus()
+		}
+		return a, nil
+	}
+	var cmd tea.Cmd
+	a.tabRenameInput, cmd = a.tabRenameInput.Update(msg)
+	return a, cmd
+}
+
+// updateTabKeys handles the tab shortcuts shared by query input and results.
+// It reports whether the key was consumed. The keys avoid the query input's
+// own bindings, such as ctrl+w to delete a word.
+func (a *App) updateTabKeys(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if len(a.tabs) == 0 {
+		a.saveActiveTab()
+	}
+	key := msg.String()
+	switch key {
+	case "alt+n":
+		return true, a.newTab()
+	case "alt+w":
+		a.closeTab()
+		return true, nil
+	case "ctrl+r":
+		return true, a.startTabRename()
+	case "shift+right":
+		a.switchTab((a.activeTab + 1) % len(a.tabs))
+		return true, nil
+	case "shift+left":
+		a.switchTab((a.activeTab - 1 + len(a.tabs)) % len(a.tabs))
+		return true, nil
+	}
+	if strings.HasPrefix(key, "alt+") && len(key) == 5 && key[4] >= '1' && key[4] <= '9' {
+		a.switchTab(int(key[4] - '1'))
+		return true, nil
+	}
+	return false, nil
+}
+
+// viewTabBar renders the tab strip shown above the query box
+func (a *App) viewTabBar() string {
+	if len(a.tabs) == 0 {
+		return ""
+	}
+	var parts []string
+	for i, tab := range a.tabs {
+		label := tab.label()
+		if i == a.activeTab {
+			label = a.currentTabLabel()
+		}
+		text := fmt.Sprintf(" %d:%s ", i+1, label)
+		if i == a.activeTab {
+			if a.tabRenaming {
+				text = fmt.Sprintf(" %d:%s ", i+1, a.tabRenameInput.View())
+			}
+			parts = append(parts, activeTabStyle.Render(text))
+		} else {
+			parts = append(parts, inactiveTabStyle.Render(text))
+		}
+	}
+	return strings.Join(parts, dimStyle.Render("│"))
+}
+
+// currentTabLabel labels the active tab from live App state, since the saved
+// copy is only refreshed on switch
+func (a *App) currentTabLabel() string {
+	tab := a.tabs[a.activeTab]
+	tab.state = a.currentNavEntry()
+	return tab.label()
+}
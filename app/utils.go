@@ -1,11 +1,16 @@
 // utils.go provides helper functions for filtering lists, cursor movement,
-// and building UI components like the column info table.
+// exporting generated text, and building UI components like the column info
+// table.
 package app
 
 import (
+	"dbsurf/config"
 	"dbsurf/db"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 )
@@ -42,7 +47,7 @@ func (a *App) filterAndRebuildColumnInfo() {
 	a.filteredColumnInfo = filterColumnInfo(a.columnInfoData, a.columnInfoFilter)
 	if len(a.filteredColumnInfo) > 0 {
 		tableHeight := min(len(a.filteredColumnInfo), 15)
-		a.columnInfoTable = buildColumnInfoTable(a.filteredColumnInfo, tableHeight)
+		a.columnInfoTable = buildColumnInfoTable(a.filteredColumnInfo, a.columnInfoFKs, tableHeight)
 	}
 }
 
@@ -57,25 +62,40 @@ func moveCursor(cursor, delta, max int) int {
 	return cursor
 }
 
-func buildColumnInfoTable(columns []db.ColumnInfo, height int) table.Model {
+// truncate shortens s to at most n characters, marking the cut with "…".
+// It counts runes so multi-byte characters are never split, and treats a
+// negative n as 0.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:max(n, 0)]) + "…"
+}
+
+// buildColumnInfoTable renders column metadata as a table. fkTargets maps
+// column names to the table.column they reference.
+func buildColumnInfoTable(columns []db.ColumnInfo, fkTargets map[string]string, height int) table.Model {
 	cols := []table.Column{
 		{Title: "Column", Width: 20},
 		{Title: "Type", Width: 18},
 		{Title: "Key", Width: 4},
 		{Title: "Nullable", Width: 10},
 		{Title: "Default", Width: 20},
+		{Title: "Extra", Width: 10},
+		{Title: "References", Width: 20},
+		{Title: "Collation", Width: 18},
 	}
 
 	rows := make([]table.Row, len(columns))
 	for i, col := range columns {
-		typeStr := col.DataType
-		if col.MaxLength != "" {
-			typeStr += fmt.Sprintf("(%s)", col.MaxLength)
-		}
+		typeStr := db.ColumnTypeSQL(col)
 
 		keyStr := ""
 		if col.IsPrimary {
 			keyStr = "PK"
+		} else if fkTargets[col.Name] != "" {
+			keyStr = "FK"
 		}
 
 		nullStr := "NULL"
@@ -85,7 +105,14 @@ func buildColumnInfoTable(columns []db.ColumnInfo, height int) table.Model {
 
 		defaultStr := col.Default
 
-		rows[i] = table.Row{col.Name, typeStr, keyStr, nullStr, defaultStr}
+		extraStr := ""
+		if col.IsIdentity {
+			extraStr = "identity"
+		} else if col.IsGenerated {
+			extraStr = "generated"
+		}
+
+		rows[i] = table.Row{col.Name, typeStr, keyStr, nullStr, defaultStr, extraStr, fkTargets[col.Name], col.Collation}
 	}
 
 	s := table.DefaultStyles()
@@ -110,3 +137,18 @@ func buildColumnInfoTable(columns []db.ColumnInfo, height int) table.Model {
 
 	return t
 }
+
+// writeExport saves generated text (scripts, reports) to the export directory
+// under a timestamped name and returns the path written
+func writeExport(prefix, ext, content string) (string, error) {
+	dir, err := config.ExportDir()
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%s.%s", prefix, time.Now().Format("20060102-150405"), ext)
+	path := filepath.Join(dir, strings.NewReplacer("/", "_", "\\", "_", " ", "_").Replace(name))
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
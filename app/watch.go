@@ -0,0 +1,241 @@
+// watch.go implements watch mode, which re-runs the current query on an
+// interval via tea.Tick. Cells that changed since the previous run are
+// highlighted, the last refresh time is shown, and watching can be paused.
+// Only read statements can be watched. The query is captured when watching
+// starts, and watching stops if the query fails or the input is edited.
+package app
+
+import (
+	"dbsurf/db"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const defaultWatchInterval = 5 * time.Second
+
+var changedCellStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("0")).
+	Background(ColorWarning)
+
+// watchTickMsg triggers a refresh. seq guards against ticks scheduled before
+// the watch was stopped, paused or restarted.
+type watchTickMsg struct {
+	seq int
+}
+
+func (a *App) scheduleWatchTick() tea.Cmd {
+	a.watchSeq++
+	seq := a.watchSeq
+	return tea.Tick(a.watchInterval, func(time.Time) tea.Msg {
+		return watchTickMsg{seq: seq}
+	})
+}
+
+func (a *App) startWatchPrompt() tea.Cmd {
+	query := a.queryInput.Value()
+	if query == "" {
+		return nil
+	}
+	if err := a.checkWatchable(query); err != nil {
+		a.queryErr = err
+		return nil
+	}
+	a.watchQuery = query
+	a.watchArgs = a.queryArgs
+	a.watchPrompting = true
+	a.watchIntervalInput.SetValue(strconv.Itoa(int(defaultWatchInterval.Seconds())))
+	a.watchIntervalInput.Focus()
+	return textinput.Blink
+}
+
+func (a *App) updateWatchPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		a.watchPrompting = false
+		a.watchIntervalInput.Blur()
+		return a, nil
+	case "enter":
+		a.watchPrompting = false
+		a.watchIntervalInput.Blur()
+		secs, err := strconv.Atoi(strings.TrimSpace(a.watchIntervalInput.Value()))
+		if err != nil || secs < 1 {
+			a.queryErr = fmt.Errorf("watch interval must be a whole number of seconds")
+			return a, nil
+		}
+		a.watchInterval = time.Duration(secs) * time.Second
+		a.watching = true
+		a.watchPaused = false
+		a.watchChanges = nil
+		a.watchLastRun = time.Now()
+		return a, a.scheduleWatchTick()
+	}
+	var cmd tea.Cmd
+	a.watchIntervalInput, cmd = a.watchIntervalInput.Update(msg)
+	return a, cmd
+}
+
+// checkWatchable refuses what the connection's safety level refuses and any
+// other write, as re-running a write on a timer would repeat it without
+// confirmation
+func (a *App) checkWatchable(query string) error {
+	if _, err := a.checkWrite(query); err != nil {
+		return err
+	}
+	if db.IsWriteStatement(query) {
+		return fmt.Errorf("cannot watch a write statement")
+	}
+	return nil
+}
+
+func (a *App) stopWatch() {
+	a.watching = false
+	a.watchPaused = false
+	a.watchChanges = nil
+	a.watchQuery = ""
+	a.watchArgs = nil
+	a.watchSeq++
+}
+
+// toggleWatchPause pauses or resumes refreshing
+func (a *App) toggleWatchPause() tea.Cmd {
+	if !a.watching {
+		return nil
+	}
+	a.watchPaused = !a.watchPaused
+	if a.watchPaused {
+		a.watchSeq++
+		return nil
+	}
+	return a.scheduleWatchTick()
+}
+
+func (a *App) handleWatchTick(msg watchTickMsg) (tea.Model, tea.Cmd) {
+	if !a.watching || a.watchPaused || msg.seq != a.watchSeq {
+		return a, nil
+	}
+	if a.queryInput.Value() != a.watchQuery {
+		// Edited without being run; the results no longer match the input
+		a.stopWatch()
+		return a, nil
+	}
+	if err := a.refreshWatch(); err != nil {
+		a.stopWatch()
+		a.queryErr = fmt.Errorf("watch stopped: %w", err)
+		return a, nil
+	}
+	return a, a.scheduleWatchTick()
+}
+
+// refreshWatch re-runs the watched query in place, keeping filter, sort and
+// cursor
+func (a *App) refreshWatch() error {
+	if err := a.checkWatchable(a.watchQuery); err != nil {
+		return err
+	}
+	result, logErr, err := a.runAudited(a.watchQuery, a.watchArgs...)
+	if err != nil {
+		return err
+	}
+	a.watchChanges = a.diffWatchResults(a.queryResult, result)
+	a.queryErr = logErr
+	a.queryResult = result
+	a.filterResults()
+	if a.resultCursor >= len(a.filteredResultRows) {
+		a.resultCursor = max(0, len(a.filteredResultRows)-1)
+	}
+	a.watchLastRun = time.Now()
+	return nil
+}
+
+// watchRowKey identifies a row across refreshes: by primary key when known,
+// otherwise by its position in the result
+func (a *App) watchRowKey(result *db.QueryResult, row []string, pos int) string {
+	if len(a.queryPKColumns) == 0 {
+		return strconv.Itoa(pos)
+	}
+	var parts []string
+	for _, pk := range a.queryPKColumns {
+		for i, col := range result.Columns {
+			if col == pk && i < len(row) {
+				parts = append(parts, row[i])
+			}
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// diffWatchResults returns the changed cells of next keyed by row, compared to prev
+func (a *App) diffWatchResults(prev, next *db.QueryResult) map[string]map[int]bool {
+	changes := make(map[string]map[int]bool)
+	if prev == nil || strings.Join(prev.Columns, ",") != strings.Join(next.Columns, ",") {
+		return changes
+	}
+
+	prevRows := make(map[string][]string, len(prev.Rows))
+	for i, row := range prev.Rows {
+		prevRows[a.watchRowKey(prev, row, i)] = row
+	}
+	for i, row := range next.Rows {
+		key := a.watchRowKey(next, row, i)
+		old, ok := prevRows[key]
+		for j := range row {
+			if !ok || j >= len(old) || old[j] != row[j] {
+				if changes[key] == nil {
+					changes[key] = make(map[int]bool)
+				}
+				changes[key][j] = true
+			}
+		}
+	}
+	return changes
+}
+
+// isCellChanged reports whether column j of the given displayed row changed
+// in the last refresh
+func (a *App) isCellChanged(row []string, j int) bool {
+	if len(a.watchChanges) == 0 {
+		return false
+	}
+	pos := 0
+	if len(a.queryPKColumns) == 0 {
+		// Displayed rows share backing arrays with queryResult.Rows
+		for i, r := range a.queryResult.Rows {
+			if len(r) > 0 && len(row) > 0 && &r[0] == &row[0] {
+				pos = i
+				break
+			}
+		}
+	}
+	return a.watchChanges[a.watchRowKey(a.queryResult, row, pos)][j]
+}
+
+func (a *App) watchChangeCount() int {
+	n := 0
+	for _, cols := range a.watchChanges {
+		n += len(cols)
+	}
+	return n
+}
+
+// viewWatchStatus renders the watch prompt or status line
+func (a *App) viewWatchStatus() string {
+	if a.watchPrompting {
+		return inputLabelStyle.Render("Refresh every (seconds): ") + a.watchIntervalInput.View()
+	}
+	if !a.watching {
+		return ""
+	}
+	state := fmt.Sprintf("Watching every %s", a.watchInterval)
+	if a.watchPaused {
+		state += " (paused)"
+	}
+	status := fmt.Sprintf(" • last refresh %s • %d cells changed",
+		a.watchLastRun.Format("15:04:05"), a.watchChangeCount())
+	return editingStyle.Render(state) + dimStyle.Render(status)
+}
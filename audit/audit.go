@@ -0,0 +1,147 @@
+// audit.go appends a record of every write statement run through dbsurf to
+// ~/.config/dbsurf/audit.log as JSON lines, rotating the file by size, and
+// reads recent entries back for the in-app viewer.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// MaxSize is the size in bytes at which the log is rotated to audit.log.1
+var MaxSize int64 = 5 << 20
+
+// maxBackups is the number of rotated files kept (audit.log.1 ... .N)
+const maxBackups = 3
+
+// Outcomes of an audited statement
+const (
+	OutcomeOK      = "ok"
+	OutcomeError   = "error"
+	OutcomeRefused = "refused" // Blocked by the connection's safety level
+)
+
+// Entry is one audited statement. Connection is the saved connection's name;
+// connection strings are never logged since they may hold passwords. Rows is
+// -1 when the affected row count is unknown.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	User       string    `json:"user"`
+	Connection string    `json:"connection"`
+	Database   string    `json:"database,omitempty"`
+	Statement  string    `json:"statement"`
+	Rows       int64     `json:"rows"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Path returns the location of the audit log, creating its directory
+func Path() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".config", "dbsurf")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// osUser returns the name of the user running dbsurf
+func osUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Append writes e to the log, filling in the time and OS user, and rotates
+// the log first if the entry would take it past MaxSize
+func Append(e Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.User == "" {
+		e.User = osUser()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > MaxSize {
+		if err := rotate(path); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate shifts audit.log.N-1 to .N, dropping the oldest, and moves the
+// current log to .1
+func rotate(path string) error {
+	os.Remove(fmt.Sprintf("%s.%d", path, maxBackups))
+	for i := maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+	}
+	return os.Rename(path, path+".1")
+}
+
+// Recent returns up to limit entries from the current log, newest first.
+// Lines that cannot be parsed are skipped.
+func Recent(limit int) ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Newest first, keeping only the last limit entries
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
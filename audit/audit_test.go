@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAppendAndRecent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	for i := range 3 {
+		err := Append(Entry{Connection: "prod", Database: "shop", Statement: fmt.Sprintf("DELETE FROM t WHERE id = %d", i), Rows: 1, Outcome: OutcomeOK})
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	entries, err := Recent(2)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Recent(2) = %d entries, want 2", len(entries))
+	}
+	if entries[0].Statement != "DELETE FROM t WHERE id = 2" || entries[1].Statement != "DELETE FROM t WHERE id = 1" {
+		t.Errorf("Recent() = %q, %q, want newest first", entries[0].Statement, entries[1].Statement)
+	}
+	if entries[0].User == "" || entries[0].Time.IsZero() {
+		t.Errorf("Append() should fill user and time, got %+v", entries[0])
+	}
+
+	path, _ := Path()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("audit log permissions = %o, want 600", perm)
+	}
+}
+
+func TestAppend_Rotates(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	orig := MaxSize
+	MaxSize = 300
+	defer func() { MaxSize = orig }()
+
+	for i := range 20 {
+		if err := Append(Entry{Connection: "c", Statement: strings.Repeat("x", 50), Rows: int64(i), Outcome: OutcomeOK}); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	path, _ := Path()
+	for _, p := range []string{path, path + ".1", path + fmt.Sprintf(".%d", maxBackups)} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", p, err)
+		}
+		if info.Size() > MaxSize {
+			t.Errorf("%s is %d bytes, want at most %d", p, info.Size(), MaxSize)
+		}
+	}
+	if _, err := os.Stat(path + fmt.Sprintf(".%d", maxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("only %d backups should be kept", maxBackups)
+	}
+
+	entries, _ := Recent(100)
+	if len(entries) == 0 || entries[0].Rows != 19 {
+		t.Errorf("Recent() after rotation should start with the last entry, got %+v", entries)
+	}
+}
@@ -6,19 +6,59 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 )
 
-// LastUsed will be used for sorting list in recent connections on dashboard
+// Safety levels restrict what can be changed through a connection.
+// Unrestricted is the zero value so existing configs keep working.
+const (
+	SafetyUnrestricted  = ""
+	SafetyConfirmWrites = "confirm-writes"
+	SafetyReadOnly      = "read-only"
+)
+
+// SafetyLevels lists the safety levels in the order they are cycled through
+var SafetyLevels = []string{SafetyUnrestricted, SafetyConfirmWrites, SafetyReadOnly}
+
+// LastUsed will be used for sorting list in recent connections on dashboard.
+// Production marks the connection for a warning colour in the frame.
+// SecretKey is set when the password has been moved out of ConnString to
+// the secret backend.
 type Connection struct {
 	Name       string    `json:"name"`
 	ConnString string    `json:"conn_string"`
 	DBType     string    `json:"db_type"`
 	LastUsed   time.Time `json:"last_used"`
+	Safety     string    `json:"safety,omitempty"`
+	Production bool      `json:"production,omitempty"`
+	SecretKey  string    `json:"secret_key,omitempty"`
 }
 
+// DefaultConfirmThreshold is the number of affected rows from which edits
+// and deletes must be confirmed by typing the table name or row count
+const DefaultConfirmThreshold = 10
+
 type Config struct {
-	Connections []Connection `json:"connections"`
+	Connections      []Connection `json:"connections"`
+	ConfirmThreshold int          `json:"confirm_threshold,omitempty"`
+	SecretBackend    string       `json:"secret_backend,omitempty"`
+	SecretCommand    string       `json:"secret_command,omitempty"`
+
+	// SecretErr is set by Load when some saved passwords could not be
+	// moved to the secret backend and are still in config.json
+	SecretErr error `json:"-"`
+
+	store SecretStore
+}
+
+// TypedConfirmThreshold returns the configured threshold, or the default
+// when unset
+func (c *Config) TypedConfirmThreshold() int {
+	if c != nil && c.ConfirmThreshold > 0 {
+		return c.ConfirmThreshold
+	}
+	return DefaultConfirmThreshold
 }
 
 func getConfigPath() (string, error) {
@@ -33,6 +73,20 @@ func getConfigPath() (string, error) {
 	return filepath.Join(configDir, "config.json"), nil
 }
 
+// ExportDir returns the directory generated scripts and reports are written
+// to, creating it if needed
+func ExportDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(home, ".config", "dbsurf", "exports")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
 func Load() (*Config, error) {
 	path, err := getConfigPath()
 	if err != nil {
@@ -51,6 +105,9 @@ func Load() (*Config, error) {
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
+	if cfg.migrateSecrets() {
+		cfg.Save()
+	}
 	return &cfg, nil
 }
 
@@ -67,20 +124,27 @@ func (c *Config) Save() error {
 	return os.WriteFile(path, data, 0600)
 }
 
-func (c *Config) AddConnection(name, connString, dbType string) {
+// AddConnection saves a connection, or renames the existing one with the
+// same connection string. The password goes to the secret backend; the
+// returned error reports when it had to be kept in config.json instead.
+func (c *Config) AddConnection(name, connString, dbType string) error {
+	stripped, password, _ := splitPassword(connString, dbType)
 	for i, conn := range c.Connections {
-		if conn.ConnString == connString {
+		// Connections with their password in the secret backend share the
+		// stripped string with any other login to the same server, so only
+		// the same password makes it the same connection
+		if conn.ConnString == connString || conn.ConnString == stripped && c.hasPassword(conn, password) {
 			c.Connections[i].Name = name
 			c.Connections[i].LastUsed = time.Now()
-			return
+			return c.storeSecret(&c.Connections[i], connString)
 		}
 	}
 	c.Connections = append(c.Connections, Connection{
-		Name:       name,
-		ConnString: connString,
-		DBType:     dbType,
-		LastUsed:   time.Now(),
+		Name:     name,
+		DBType:   dbType,
+		LastUsed: time.Now(),
 	})
+	return c.storeSecret(&c.Connections[len(c.Connections)-1], connString)
 }
 
 func (c *Config) UpdateLastUsed(connString string) {
@@ -91,3 +155,33 @@ func (c *Config) UpdateLastUsed(connString string) {
 		}
 	}
 }
+
+// Is reports whether c and other are the same saved connection. Connection
+// strings without their password can be shared, so the secret key is
+// compared too.
+func (c Connection) Is(other Connection) bool {
+	return c.ConnString == other.ConnString && c.SecretKey == other.SecretKey
+}
+
+// CycleSafety moves the connection to the next safety level and returns it
+func (c *Config) CycleSafety(target Connection) string {
+	for i, conn := range c.Connections {
+		if conn.Is(target) {
+			next := (slices.Index(SafetyLevels, conn.Safety) + 1) % len(SafetyLevels)
+			c.Connections[i].Safety = SafetyLevels[next]
+			return SafetyLevels[next]
+		}
+	}
+	return SafetyUnrestricted
+}
+
+// ToggleProduction flips the connection's production flag and returns it
+func (c *Config) ToggleProduction(target Connection) bool {
+	for i, conn := range c.Connections {
+		if conn.Is(target) {
+			c.Connections[i].Production = !conn.Production
+			return c.Connections[i].Production
+		}
+	}
+	return false
+}
@@ -0,0 +1,460 @@
+// secrets.go keeps connection passwords out of config.json. When a
+// connection is saved its password is split from the connection string and
+// handed to a secret backend: the Secret Service keyring (through
+// secret-tool), a file encrypted with a master passphrase, or an external
+// command such as `pass show` or `op read`. Connecting puts it back.
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Secret backends selectable with secret_backend in config.json. Auto uses
+// the keyring when secret-tool is available, else the encrypted file when a
+// passphrase is set, else keeps passwords in config.json as before.
+const (
+	BackendAuto    = ""
+	BackendNone    = "none"
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+	BackendCommand = "command"
+)
+
+// PassphraseEnv names the environment variable holding the master
+// passphrase of the encrypted file backend
+const PassphraseEnv = "DBSURF_PASSPHRASE"
+
+// SecretStore holds passwords by key
+type SecretStore interface {
+	Get(key string) (string, error)
+	Set(key, secret string) error
+	Delete(key string) error
+}
+
+// secretStore returns the configured backend, or nil when passwords stay
+// in config.json
+func (c *Config) secretStore() (SecretStore, error) {
+	if c.store != nil {
+		return c.store, nil
+	}
+	switch c.SecretBackend {
+	case BackendNone:
+		return nil, nil
+	case BackendKeyring:
+		c.store = keyringStore{}
+	case BackendFile:
+		passphrase := os.Getenv(PassphraseEnv)
+		if passphrase == "" {
+			return nil, fmt.Errorf("the file secret backend needs a passphrase in %s", PassphraseEnv)
+		}
+		path, err := secretsPath()
+		if err != nil {
+			return nil, err
+		}
+		c.store = &fileStore{path: path, passphrase: passphrase}
+	case BackendCommand:
+		if c.SecretCommand == "" {
+			return nil, errors.New("the command secret backend needs secret_command in config.json")
+		}
+		c.store = commandStore{command: c.SecretCommand}
+	case BackendAuto:
+		if _, err := exec.LookPath("secret-tool"); err == nil && runtime.GOOS == "linux" {
+			c.store = keyringStore{}
+		} else if os.Getenv(PassphraseEnv) != "" {
+			c.SecretBackend = BackendFile
+			return c.secretStore()
+		}
+	default:
+		return nil, fmt.Errorf("unknown secret backend %q", c.SecretBackend)
+	}
+	return c.store, nil
+}
+
+// storeSecret sets conn's connection string, moving its password to the
+// secret backend. Without a backend, or when the backend fails, the
+// connection string is kept as is so no credentials are lost.
+func (c *Config) storeSecret(conn *Connection, connString string) error {
+	conn.ConnString = connString
+	stripped, password, ok := splitPassword(connString, conn.DBType)
+	if !ok && !hasKeywordPassword(connString) {
+		return nil
+	}
+	store, err := c.secretStore()
+	if store == nil {
+		if err != nil {
+			return fmt.Errorf("password kept in config.json: %w", err)
+		}
+		return nil
+	}
+	if !ok {
+		return errors.New("password kept in config.json: only passwords in URLs and MySQL DSNs can be moved to the secret backend")
+	}
+	key := conn.SecretKey
+	if key == "" {
+		key = c.newSecretKey(conn.Name)
+	}
+	if err := store.Set(key, password); err != nil {
+		return fmt.Errorf("password kept in config.json: %w", err)
+	}
+	conn.ConnString = stripped
+	conn.SecretKey = key
+	return nil
+}
+
+// migrateSecrets moves plaintext passwords saved by older versions to the
+// secret backend and reports whether any were moved. Connections whose
+// password stays in config.json are listed in SecretErr.
+func (c *Config) migrateSecrets() bool {
+	moved := false
+	var errs []error
+	for i := range c.Connections {
+		conn := &c.Connections[i]
+		if conn.SecretKey != "" {
+			continue
+		}
+		if err := c.storeSecret(conn, conn.ConnString); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", conn.Name, err))
+		} else if conn.SecretKey != "" {
+			moved = true
+		}
+	}
+	c.SecretErr = errors.Join(errs...)
+	return moved
+}
+
+// Password returns conn's password from the secret backend, or "" when its
+// connection string holds its own credentials. The password is inserted
+// with db.WithPassword after ${...} references are expanded, so it is never
+// expanded itself.
+func (c *Config) Password(conn Connection) (string, error) {
+	if conn.SecretKey == "" {
+		return "", nil
+	}
+	store, err := c.secretStore()
+	if err != nil {
+		return "", err
+	}
+	if store == nil {
+		return "", fmt.Errorf("the password for %s is in a secret backend, but none is configured", conn.Name)
+	}
+	password, err := store.Get(conn.SecretKey)
+	if err != nil {
+		return "", fmt.Errorf("reading password for %s: %w", conn.Name, err)
+	}
+	return password, nil
+}
+
+// hasPassword reports whether the secret backend holds password for conn
+func (c *Config) hasPassword(conn Connection, password string) bool {
+	if conn.SecretKey == "" || password == "" {
+		return false
+	}
+	store, _ := c.secretStore()
+	if store == nil {
+		return false
+	}
+	stored, err := store.Get(conn.SecretKey)
+	return err == nil && stored == password
+}
+
+// DeleteSecret removes conn's password from the secret backend
+func (c *Config) DeleteSecret(conn Connection) error {
+	if conn.SecretKey == "" {
+		return nil
+	}
+	store, err := c.secretStore()
+	if store == nil {
+		return err
+	}
+	return store.Delete(conn.SecretKey)
+}
+
+// newSecretKey derives a readable, unused key from a connection name so it
+// can be found in the user's secret manager
+func (c *Config) newSecretKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	base := strings.TrimSuffix(b.String(), "-")
+	if base == "" {
+		base = "connection"
+	}
+
+	taken := func(key string) bool {
+		for _, conn := range c.Connections {
+			if conn.SecretKey == key {
+				return true
+			}
+		}
+		return false
+	}
+	key := base
+	for n := 2; taken(key); n++ {
+		key = fmt.Sprintf("%s-%d", base, n)
+	}
+	return key
+}
+
+// keywordPasswordRe matches a password given as a keyword, as in Postgres
+// keyword/value strings (password=...), SQL Server ADO strings (pwd=...) or
+// URL parameters, none of which splitPassword can separate
+var keywordPasswordRe = regexp.MustCompile(`(?i)(?:^|[\s;?&])(?:password|pwd)\s*=\s*([^\s;&]+)`)
+
+// hasKeywordPassword reports whether connString holds a password that
+// splitPassword leaves in place. ${...} references hold no secret.
+func hasKeywordPassword(connString string) bool {
+	for _, m := range keywordPasswordRe.FindAllStringSubmatch(connString, -1) {
+		if !strings.HasPrefix(m[1], "${") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPassword removes the password from a connection string. MySQL uses
+// user:pass@tcp(host)/db DSNs, the other engines URLs. Passwords given as a
+// ${...} reference are left in place since they hold no secret.
+func splitPassword(connString, dbType string) (stripped, password string, ok bool) {
+	if dbType == "mysql" {
+		at := strings.LastIndex(connString, "@")
+		if at == -1 {
+			return connString, "", false
+		}
+		user, password, ok := strings.Cut(connString[:at], ":")
+		if !ok || password == "" || strings.Contains(password, "${") {
+			return connString, "", false
+		}
+		return user + connString[at:], password, true
+	}
+
+	u, err := url.Parse(connString)
+	if err != nil || u.User == nil {
+		return connString, "", false
+	}
+	password, ok = u.User.Password()
+	if !ok || password == "" || strings.Contains(password, "${") {
+		return connString, "", false
+	}
+	u.User = url.User(u.User.Username())
+	return u.String(), password, true
+}
+
+// commandOutput runs cmd and returns its stdout, folding stderr into the
+// error so failures from the secret manager are readable
+func commandOutput(cmd *exec.Cmd) (string, error) {
+	out, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return "", fmt.Errorf("%s: %s", filepath.Base(cmd.Path), strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	}
+	return string(out), nil
+}
+
+// keyringStore uses the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool, with the attributes service=dbsurf and account=<key>
+type keyringStore struct{}
+
+func (keyringStore) Get(key string) (string, error) {
+	out, err := commandOutput(exec.Command("secret-tool", "lookup", "service", "dbsurf", "account", key))
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", fmt.Errorf("no password stored in the keyring for %q", key)
+	}
+	return out, nil
+}
+
+func (keyringStore) Set(key, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=dbsurf: "+key, "service", "dbsurf", "account", key)
+	cmd.Stdin = strings.NewReader(secret)
+	_, err := commandOutput(cmd)
+	return err
+}
+
+func (keyringStore) Delete(key string) error {
+	_, err := commandOutput(exec.Command("secret-tool", "clear", "service", "dbsurf", "account", key))
+	return err
+}
+
+// commandStore reads passwords from an external command, with {key}
+// replaced by the connection's key, e.g. `pass show dbsurf/{key}` or
+// `op read op://Private/dbsurf-{key}/password`. The first line of output is
+// the password. Passwords are managed in the external tool, so Set only
+// checks that the password is already there.
+type commandStore struct {
+	command string
+}
+
+func (s commandStore) Get(key string) (string, error) {
+	out, err := commandOutput(exec.Command("sh", "-c", strings.ReplaceAll(s.command, "{key}", key)))
+	if err != nil {
+		return "", err
+	}
+	password, _, _ := strings.Cut(out, "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", fmt.Errorf("secret command returned no password for %q", key)
+	}
+	return password, nil
+}
+
+func (s commandStore) Set(key, secret string) error {
+	if got, err := s.Get(key); err == nil && got == secret {
+		return nil
+	}
+	return fmt.Errorf("add the password to your secret manager under %q", key)
+}
+
+func (commandStore) Delete(string) error {
+	return nil
+}
+
+// pbkdf2Iterations is the key derivation cost of the encrypted file
+var pbkdf2Iterations = 600_000
+
+const saltSize = 16
+
+// fileStore keeps passwords in ~/.config/dbsurf/secrets.enc as JSON sealed
+// with AES-256-GCM, keyed by PBKDF2-SHA256 of the master passphrase. The
+// file is salt || nonce || ciphertext and is decrypted once per run.
+type fileStore struct {
+	path       string
+	passphrase string
+
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+func secretsPath() (string, error) {
+	path, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "secrets.enc"), nil
+}
+
+func (s *fileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	secret, ok := s.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("no password stored in %s for %q", filepath.Base(s.path), key)
+	}
+	return secret, nil
+}
+
+func (s *fileStore) Set(key, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.secrets[key] = secret
+	return s.save()
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	delete(s.secrets, key)
+	return s.save()
+}
+
+func (s *fileStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.secrets = map[string]string{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(data) < saltSize {
+		return fmt.Errorf("%s is corrupted", filepath.Base(s.path))
+	}
+	aead, err := fileCipher(s.passphrase, data[:saltSize])
+	if err != nil {
+		return err
+	}
+	data = data[saltSize:]
+	if len(data) < aead.NonceSize() {
+		return fmt.Errorf("%s is corrupted", filepath.Base(s.path))
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt %s: wrong passphrase or corrupted file", filepath.Base(s.path))
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return fmt.Errorf("%s is corrupted", filepath.Base(s.path))
+	}
+	if secrets == nil {
+		secrets = map[string]string{}
+	}
+	s.secrets = secrets
+	return nil
+}
+
+func (s *fileStore) save() error {
+	plain, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, saltSize)
+	rand.Read(salt)
+	aead, err := fileCipher(s.passphrase, salt)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+
+	data := append(salt, nonce...)
+	data = aead.Seal(data, nonce, plain, nil)
+	return os.WriteFile(s.path, data, 0600)
+}
+
+func fileCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
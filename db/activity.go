@@ -0,0 +1,227 @@
+// activity.go lists the sessions connected to a server with what they are
+// running and which sessions block them, and cancels or kills sessions.
+// It reads pg_stat_activity, information_schema.PROCESSLIST and
+// sys.dm_exec_sessions/sys.dm_exec_requests.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerSession is one client session on the server. BlockedBy holds the IDs
+// of sessions holding locks this session is waiting on.
+type ServerSession struct {
+	ID        string
+	User      string
+	Database  string
+	Host      string
+	State     string
+	Wait      string
+	Duration  time.Duration // Time in the current query or state
+	Query     string
+	BlockedBy []string
+}
+
+// IsIdle reports whether the session is connected but not running anything
+func (s ServerSession) IsIdle() bool {
+	switch strings.ToLower(s.State) {
+	case "idle", "sleep", "sleeping":
+		return true
+	}
+	return false
+}
+
+// ListSessions returns the server's client sessions, excluding the one used
+// to run this query, longest running first
+func ListSessions(db *sql.DB, dbType string) ([]ServerSession, error) {
+	var query string
+	switch dbType {
+	case "postgres":
+		query = `SELECT pid::text, COALESCE(usename, ''), COALESCE(datname, ''), COALESCE(client_addr::text, ''),
+				COALESCE(state, ''), COALESCE(wait_event_type || ': ' || wait_event, ''),
+				COALESCE(EXTRACT(EPOCH FROM now() - COALESCE(query_start, backend_start)) * 1000, 0)::bigint,
+				COALESCE(query, ''), array_to_string(pg_blocking_pids(pid), ',')
+			FROM pg_stat_activity
+			WHERE pid <> pg_backend_pid() AND backend_type = 'client backend'`
+	case "mysql":
+		query = `SELECT ID, COALESCE(USER, ''), COALESCE(DB, ''), COALESCE(HOST, ''),
+				CASE WHEN COMMAND = 'Query' THEN COALESCE(NULLIF(STATE, ''), 'Query') ELSE COMMAND END,
+				'', TIME * 1000, COALESCE(INFO, ''), ''
+			FROM information_schema.PROCESSLIST
+			WHERE ID <> CONNECTION_ID() AND COMMAND NOT IN ('Daemon', 'Binlog Dump')`
+	case "sqlserver":
+		query = `SELECT s.session_id, s.login_name, COALESCE(DB_NAME(COALESCE(r.database_id, s.database_id)), ''),
+				COALESCE(s.host_name, ''), COALESCE(r.status, s.status), COALESCE(r.wait_type, ''),
+				DATEDIFF_BIG(ms, COALESCE(r.start_time, s.last_request_start_time), GETDATE()),
+				COALESCE(t.text, ''), COALESCE(CAST(NULLIF(r.blocking_session_id, 0) AS varchar(10)), '')
+			FROM sys.dm_exec_sessions s
+			LEFT JOIN sys.dm_exec_requests r ON r.session_id = s.session_id
+			OUTER APPLY sys.dm_exec_sql_text(r.sql_handle) t
+			WHERE s.is_user_process = 1 AND s.session_id <> @@SPID`
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	rows, err := db.Query(query + " ORDER BY 7 DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []ServerSession
+	for rows.Next() {
+		var s ServerSession
+		var ms int64
+		var blockedBy string
+		rows.Scan(&s.ID, &s.User, &s.Database, &s.Host, &s.State, &s.Wait, &ms, &s.Query, &blockedBy)
+		s.Duration = time.Duration(ms) * time.Millisecond
+		if blockedBy != "" {
+			s.BlockedBy = strings.Split(blockedBy, ",")
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if dbType == "mysql" {
+		addMySQLLockWaits(db, sessions)
+	}
+	return sessions, nil
+}
+
+// addMySQLLockWaits fills BlockedBy from InnoDB lock waits. The sys schema is
+// not always installed, so failures leave the sessions without blockers.
+func addMySQLLockWaits(db *sql.DB, sessions []ServerSession) {
+	rows, err := db.Query("SELECT waiting_pid, blocking_pid FROM sys.innodb_lock_waits")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	index := make(map[string]int, len(sessions))
+	for i, s := range sessions {
+		index[s.ID] = i
+	}
+	for rows.Next() {
+		var waiting, blocking string
+		rows.Scan(&waiting, &blocking)
+		if i, ok := index[waiting]; ok {
+			sessions[i].BlockedBy = append(sessions[i].BlockedBy, blocking)
+		}
+	}
+}
+
+// CancelSession stops the session's running query but keeps it connected.
+// SQL Server cannot cancel another session's request, so it is killed.
+func CancelSession(db *sql.DB, id, dbType string) error {
+	if _, err := strconv.Atoi(id); err != nil {
+		return fmt.Errorf("invalid session id: %q", id)
+	}
+	switch dbType {
+	case "postgres":
+		return checkSignal(db, "SELECT pg_cancel_backend("+id+")", id)
+	case "mysql":
+		_, err := db.Exec("KILL QUERY " + id)
+		return err
+	case "sqlserver":
+		return KillSession(db, id, dbType)
+	}
+	return fmt.Errorf("unsupported database type: %s", dbType)
+}
+
+// KillSession terminates the session, rolling back its open transaction
+func KillSession(db *sql.DB, id, dbType string) error {
+	if _, err := strconv.Atoi(id); err != nil {
+		return fmt.Errorf("invalid session id: %q", id)
+	}
+	switch dbType {
+	case "postgres":
+		return checkSignal(db, "SELECT pg_terminate_backend("+id+")", id)
+	case "mysql", "sqlserver":
+		_, err := db.Exec("KILL " + id)
+		return err
+	}
+	return fmt.Errorf("unsupported database type: %s", dbType)
+}
+
+// checkSignal runs a Postgres pg_cancel_backend/pg_terminate_backend call,
+// which returns false rather than failing when the session is gone
+func checkSignal(db *sql.DB, query, id string) error {
+	var ok bool
+	if err := db.QueryRow(query).Scan(&ok); err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("session %s could not be signalled (already ended?)", id)
+	}
+	return nil
+}
+
+// LockTreeEntry is one row of a blocking tree
+type LockTreeEntry struct {
+	Session ServerSession
+	Depth   int
+}
+
+// LockTree arranges the sessions involved in lock waits as a tree: each
+// session that blocks others but is not itself blocked is a root, with the
+// sessions waiting on it nested below. Sessions not involved in any lock
+// wait are omitted. Deadlock cycles without a root are listed from their
+// lowest-ordered member.
+func LockTree(sessions []ServerSession) []LockTreeEntry {
+	byID := make(map[string]ServerSession, len(sessions))
+	waiters := make(map[string][]string)
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+	for _, s := range sessions {
+		for _, blocker := range s.BlockedBy {
+			waiters[blocker] = append(waiters[blocker], s.ID)
+		}
+	}
+
+	var tree []LockTreeEntry
+	seen := make(map[string]bool)
+	var walk func(id string, depth int)
+	walk = func(id string, depth int) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		s, ok := byID[id]
+		if !ok {
+			// Blocker that is not in the list, e.g. the monitoring session
+			s = ServerSession{ID: id, State: "unknown"}
+		}
+		tree = append(tree, LockTreeEntry{Session: s, Depth: depth})
+		for _, w := range waiters[id] {
+			walk(w, depth+1)
+		}
+	}
+
+	for _, s := range sessions {
+		if len(waiters[s.ID]) > 0 && len(s.BlockedBy) == 0 {
+			walk(s.ID, 0)
+		}
+	}
+	// Blockers missing from the session list
+	for _, s := range sessions {
+		for _, blocker := range s.BlockedBy {
+			if _, ok := byID[blocker]; !ok {
+				walk(blocker, 0)
+			}
+		}
+	}
+	// Cycles
+	for _, s := range sessions {
+		if len(s.BlockedBy) > 0 && !seen[s.ID] {
+			walk(s.ID, 0)
+		}
+	}
+	return tree
+}
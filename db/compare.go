@@ -0,0 +1,243 @@
+// compare.go compares the contents of a table across two connections. Rows
+// are joined on the primary key in Go rather than merged in ORDER BY order,
+// since the two databases may collate the keys differently. The target table
+// is held in memory, so memory grows with its size; the source is streamed.
+// It can also generate the statements that would reconcile the target with
+// the source.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Kinds of TableRowDiff
+const (
+	RowMissing   = "missing"   // Row exists in source only
+	RowExtra     = "extra"     // Row exists in target only
+	RowDifferent = "different" // Row exists on both sides with different values
+)
+
+// TableSide identifies one side of a cross-connection comparison
+type TableSide struct {
+	DB     *sql.DB
+	DBName string
+	DBType string
+}
+
+// TableRowDiff is a single row that does not match between source and target
+type TableRowDiff struct {
+	Kind    string
+	Key     []string // PK values
+	Source  []string // Nil for extra rows
+	Target  []string // Nil for missing rows, aligned to source columns
+	Changed []int    // Column indexes that differ
+}
+
+// TableComparison is the outcome of CompareTable
+type TableComparison struct {
+	Table     string
+	Columns   []string
+	PKColumns []string
+	Matching  int
+	Missing   int
+	Extra     int
+	Different int
+	Diffs     []TableRowDiff // Capped at maxDiffs; counts above are complete
+}
+
+// CompareTable joins tableName on both sides by the source's primary key and
+// reports differing rows, keeping at most maxDiffs of them.
+func CompareTable(source, target TableSide, tableName string, maxDiffs int) (*TableComparison, error) {
+	// GetPrimaryKey splits the schema itself; SQL Server tables outside dbo
+	// need it
+	pk, err := GetPrimaryKey(source.DB, source.DBName, tableName, source.DBType)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf("%s has no primary key", tableName)
+	}
+
+	srcRows, srcCols, err := streamTable(source, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	defer srcRows.Close()
+	dstRows, dstCols, err := streamTable(target, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	defer dstRows.Close()
+
+	// Align target columns to the source's column order
+	dstPos := make(map[string]int, len(dstCols))
+	for i, col := range dstCols {
+		dstPos[col] = i
+	}
+	align := make([]int, len(srcCols))
+	for i, col := range srcCols {
+		j, ok := dstPos[col]
+		if !ok {
+			return nil, fmt.Errorf("column %s is missing on target", col)
+		}
+		align[i] = j
+	}
+	pkIdx := make([]int, len(pk))
+	for i, col := range pk {
+		for j, c := range srcCols {
+			if c == col {
+				pkIdx[i] = j
+			}
+		}
+	}
+
+	next := func(rows *sql.Rows, n int, aligned bool) func() []string {
+		return func() []string {
+			if !rows.Next() {
+				return nil
+			}
+			row := scanRow(rows, n)
+			if !aligned {
+				return row
+			}
+			out := make([]string, len(align))
+			for i, j := range align {
+				out[i] = row[j]
+			}
+			return out
+		}
+	}
+
+	cmp := &TableComparison{Table: tableName, Columns: srcCols, PKColumns: pk}
+	joinRows(cmp, pkIdx, next(srcRows, len(srcCols), false), next(dstRows, len(dstCols), true), maxDiffs)
+
+	if err := srcRows.Err(); err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if err := dstRows.Err(); err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+	return cmp, nil
+}
+
+// streamTable selects every row of tableName. No ORDER BY is issued as
+// joinRows does not depend on row order.
+func streamTable(side TableSide, tableName string) (*sql.Rows, []string, error) {
+	query := "SELECT * FROM " + QualifiedTableName(side.DBName, tableName, side.DBType)
+
+	rows, err := side.DB.Query(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	return rows, cols, nil
+}
+
+// joinRows matches source rows to target rows on the primary key columns at
+// pkIdx and records the differences in cmp. Every target row is held in a
+// map, so the result does not depend on row order or on how each database
+// collates the keys, at the cost of memory proportional to the target
+// table. Extra rows are reported in the order the target returned them.
+func joinRows(cmp *TableComparison, pkIdx []int, nextSrc, nextDst func() []string, maxDiffs int) {
+	record := func(d TableRowDiff) {
+		if len(cmp.Diffs) < maxDiffs {
+			cmp.Diffs = append(cmp.Diffs, d)
+		}
+	}
+	keyOf := func(row []string) []string {
+		key := make([]string, len(pkIdx))
+		for i, idx := range pkIdx {
+			key[i] = row[idx]
+		}
+		return key
+	}
+
+	targets := make(map[string][]string)
+	var order []string
+	for dst := nextDst(); dst != nil; dst = nextDst() {
+		k := strings.Join(keyOf(dst), "\x00")
+		targets[k] = dst
+		order = append(order, k)
+	}
+
+	for src := nextSrc(); src != nil; src = nextSrc() {
+		k := strings.Join(keyOf(src), "\x00")
+		dst, ok := targets[k]
+		if !ok {
+			cmp.Missing++
+			record(TableRowDiff{Kind: RowMissing, Key: keyOf(src), Source: src})
+			continue
+		}
+		delete(targets, k)
+
+		var changed []int
+		for i := range src {
+			if src[i] != dst[i] {
+				changed = append(changed, i)
+			}
+		}
+		if len(changed) == 0 {
+			cmp.Matching++
+		} else {
+			cmp.Different++
+			record(TableRowDiff{Kind: RowDifferent, Key: keyOf(src), Source: src, Target: dst, Changed: changed})
+		}
+	}
+
+	for _, k := range order {
+		if dst, ok := targets[k]; ok {
+			cmp.Extra++
+			record(TableRowDiff{Kind: RowExtra, Key: keyOf(dst), Target: dst})
+		}
+	}
+}
+
+// ReconcileScript generates the INSERT, UPDATE and DELETE statements that
+// would make the target table match the source for the recorded diffs
+func ReconcileScript(cmp *TableComparison, target TableSide) string {
+	table := QualifiedTableName(target.DBName, cmp.Table, target.DBType)
+	quoted := make([]string, len(cmp.Columns))
+	for i, col := range cmp.Columns {
+		quoted[i] = QuoteIdent(col, target.DBType)
+	}
+	where := func(key []string) string {
+		parts := make([]string, len(key))
+		for i, v := range key {
+			parts[i] = QuoteIdent(cmp.PKColumns[i], target.DBType) + " = " + SQLLiteral(v)
+		}
+		return strings.Join(parts, " AND ")
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Reconcile %s: %d missing, %d extra, %d different\n",
+		cmp.Table, cmp.Missing, cmp.Extra, cmp.Different)
+	if len(cmp.Diffs) < cmp.Missing+cmp.Extra+cmp.Different {
+		fmt.Fprintf(&b, "-- WARNING: only the first %d differences are included\n", len(cmp.Diffs))
+	}
+	for _, d := range cmp.Diffs {
+		switch d.Kind {
+		case RowMissing:
+			values := make([]string, len(d.Source))
+			for i, v := range d.Source {
+				values[i] = SQLLiteral(v)
+			}
+			fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES (%s);\n",
+				table, strings.Join(quoted, ", "), strings.Join(values, ", "))
+		case RowExtra:
+			fmt.Fprintf(&b, "DELETE FROM %s WHERE %s;\n", table, where(d.Key))
+		case RowDifferent:
+			sets := make([]string, len(d.Changed))
+			for i, idx := range d.Changed {
+				sets[i] = quoted[idx] + " = " + SQLLiteral(d.Source[idx])
+			}
+			fmt.Fprintf(&b, "UPDATE %s SET %s WHERE %s;\n", table, strings.Join(sets, ", "), where(d.Key))
+		}
+	}
+	return b.String()
+}
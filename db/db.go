@@ -6,6 +6,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -24,12 +25,56 @@ func DetectDBType(connString string) string {
 	return "mysql"
 }
 
+// ConnectOptions are applied to a connection string after its references
+// are expanded
+type ConnectOptions struct {
+	// Password from a secret backend, inserted as is and masked in errors
+	Password string
+	// ReadOnly opens the connection with ReadOnlyConnString
+	ReadOnly bool
+	// Database switches a Postgres connection with WithDatabase
+	Database string
+}
+
+// Connect opens and pings a connection. ${ENV_VAR} and ${file:/path}
+// references in connString are expanded first; errors mask their values.
 func Connect(connString string) (*sql.DB, error) {
+	return ConnectWith(connString, ConnectOptions{})
+}
+
+// ConnectWith is Connect with opts applied to the expanded connection string.
+// References are expanded exactly once, before the password is inserted, so
+// neither expanded values nor the password are expanded again.
+func ConnectWith(connString string, opts ConnectOptions) (*sql.DB, error) {
+	connString, secrets, err := expandConnString(connString)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Password != "" {
+		connString = WithPassword(connString, opts.Password)
+		secrets = append(secrets, opts.Password)
+	}
+	if opts.Database != "" {
+		if connString, err = WithDatabase(connString, opts.Database); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ReadOnly {
+		if connString, err = ReadOnlyConnString(connString); err != nil {
+			return nil, err
+		}
+	}
+
 	dbType := DetectDBType(connString)
+	if dbType == "mysql" {
+		// Report matched rather than changed rows so ExecExpecting can
+		// compare against a COUNT of the WHERE clause
+		connString = mysqlWithParam(connString, "clientFoundRows", "true")
+	}
 
 	db, err := sql.Open(dbType, connString)
 	if err != nil {
-		return nil, err
+		return nil, redactError(err, secrets)
 	}
 
 	// Configure pool for single-user TUI
@@ -38,12 +83,102 @@ func Connect(connString string) (*sql.DB, error) {
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("connection failed: %w", err)
+		return nil, fmt.Errorf("connection failed: %w", redactError(err, secrets))
 	}
 
 	return db, nil
 }
 
+// ReadOnlyConnString adds driver parameters that make every transaction on
+// the connection read-only, as a second line of defence behind the app's own
+// checks: lib/pq and the MySQL driver pass unknown parameters to the server
+// as session settings. SQL Server has no equivalent and is returned as is.
+func ReadOnlyConnString(connString string) (string, error) {
+	switch DetectDBType(connString) {
+	case "postgres":
+		u, err := url.Parse(connString)
+		if err != nil {
+			return "", fmt.Errorf("cannot make connection read-only: connection string is not a URL")
+		}
+		q := u.Query()
+		q.Set("default_transaction_read_only", "on")
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	case "mysql":
+		return mysqlWithParam(connString, "transaction_read_only", "1"), nil
+	}
+	return connString, nil
+}
+
+// mysqlWithParam adds a parameter to a MySQL DSN unless it is already set
+func mysqlWithParam(dsn, key, value string) string {
+	// user:pass@tcp(host:port)/db?params; the /db part is optional and
+	// the password may contain any character, so look after the host
+	host := dsn[strings.LastIndex(dsn, "@")+1:]
+	base, params, hasParams := strings.Cut(host, "?")
+	if hasParams {
+		for _, p := range strings.Split(params, "&") {
+			if strings.HasPrefix(p, key+"=") {
+				return dsn
+			}
+		}
+		return dsn + "&" + key + "=" + value
+	}
+	if i := strings.Index(base, ")"); i != -1 && !strings.Contains(base[i:], "/") {
+		dsn += "/"
+	}
+	return dsn + "?" + key + "=" + value
+}
+
+// WithDatabase returns a Postgres connection URL pointing at another database.
+// Postgres has no USE, so switching databases means reconnecting. The error
+// deliberately omits the connection string, which may hold a password.
+func WithDatabase(connString, dbName string) (string, error) {
+	u, err := url.Parse(connString)
+	if err != nil {
+		return "", fmt.Errorf("cannot switch database: connection string is not a URL")
+	}
+	u.Path = "/" + dbName
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// WithPassword returns connString with password set for its user. A
+// connection string without a user is returned as is.
+func WithPassword(connString, password string) string {
+	if DetectDBType(connString) == "mysql" {
+		at := strings.LastIndex(connString, "@")
+		if at == -1 {
+			return connString
+		}
+		user, _, _ := strings.Cut(connString[:at], ":")
+		return user + ":" + password + connString[at:]
+	}
+
+	u, err := url.Parse(connString)
+	if err != nil || u.User == nil {
+		return connString
+	}
+	u.User = url.UserPassword(u.User.Username(), password)
+	return u.String()
+}
+
+// CurrentDatabase returns the name of the database the connection is using
+func CurrentDatabase(db *sql.DB, dbType string) (string, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = "SELECT COALESCE(DATABASE(), '')"
+	case "postgres":
+		query = "SELECT current_database()"
+	case "sqlserver":
+		query = "SELECT DB_NAME()"
+	}
+	var name string
+	err := db.QueryRow(query).Scan(&name)
+	return name, err
+}
+
 func ListDatabases(db *sql.DB, dbType string) ([]string, error) {
 	var query string
 	switch dbType {
@@ -82,14 +217,58 @@ func UseDatabase(db *sql.DB, name, dbType string) error {
 	return err
 }
 
+// pgUserSchemas returns a predicate on a schema name column that excludes
+// Postgres system schemas
+func pgUserSchemas(column string) string {
+	return fmt.Sprintf("%[1]s NOT IN ('pg_catalog', 'information_schema') AND %[1]s NOT LIKE 'pg\\_%%'", column)
+}
+
+// ListSchemas returns the user schemas of a database. MySQL has no schemas
+// separate from databases, so it returns none.
+func ListSchemas(db *sql.DB, dbName, dbType string) ([]string, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		return nil, nil
+	case "postgres":
+		query = "SELECT nspname FROM pg_namespace WHERE " + pgUserSchemas("nspname") + " ORDER BY nspname"
+	case "sqlserver":
+		query = fmt.Sprintf(`
+			SELECT DISTINCT s.name
+			FROM [%s].sys.schemas s
+			JOIN [%s].sys.objects o ON o.schema_id = s.schema_id
+			WHERE o.is_ms_shipped = 0
+			ORDER BY s.name`, dbName, dbName)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schemas []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		schemas = append(schemas, name)
+	}
+	return schemas, nil
+}
+
 func ListTables(db *sql.DB, dbName, dbType string) ([]string, error) {
 	var query string
-	var includeSchema bool
+	var includeSchema, includeType bool
 	switch dbType {
 	case "mysql":
-		query = "SHOW TABLES"
+		query = "SHOW FULL TABLES"
+		if dbName != "" {
+			query += " FROM " + QuoteIdent(dbName, dbType)
+		}
+		query += " WHERE Table_type = 'BASE TABLE'"
+		includeType = true
 	case "postgres":
-		query = "SELECT tablename FROM pg_tables WHERE schemaname = 'public'"
+		query = "SELECT schemaname || '.' || tablename FROM pg_tables WHERE " + pgUserSchemas("schemaname") + " ORDER BY 1"
 	case "sqlserver":
 		query = fmt.Sprintf("SELECT TABLE_SCHEMA, TABLE_NAME FROM [%s].INFORMATION_SCHEMA.TABLES WHERE TABLE_TYPE = 'BASE TABLE'", dbName)
 		includeSchema = true
@@ -107,6 +286,10 @@ func ListTables(db *sql.DB, dbName, dbType string) ([]string, error) {
 			var schema, name string
 			rows.Scan(&schema, &name)
 			tables = append(tables, fmt.Sprintf("[%s].[%s]", schema, name))
+		} else if includeType {
+			var name, tableType string
+			rows.Scan(&name, &tableType)
+			tables = append(tables, name)
 		} else {
 			var name string
 			rows.Scan(&name)
@@ -117,8 +300,9 @@ func ListTables(db *sql.DB, dbName, dbType string) ([]string, error) {
 }
 
 type QueryResult struct {
-	Columns []string
-	Rows    [][]string
+	Columns     []string
+	ColumnTypes []string // Database type names (e.g. INT4, VARCHAR), empty when unknown
+	Rows        [][]string
 }
 
 func GetPrimaryKey(db *sql.DB, dbName, tableName, dbType string) ([]string, error) {
@@ -136,7 +320,7 @@ func GetPrimaryKey(db *sql.DB, dbName, tableName, dbType string) ([]string, erro
 			FROM pg_index i
 			JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
 			WHERE i.indrelid = '%s'::regclass AND i.indisprimary
-			ORDER BY array_position(i.indkey, a.attnum)`, tableName)
+			ORDER BY array_position(i.indkey, a.attnum)`, pgRegclass(tableName))
 	case "sqlserver":
 		cleanTable := CleanTableName(tableName, dbType)
 		schema := ExtractSchema(tableName, dbType)
@@ -169,12 +353,17 @@ func GetPrimaryKey(db *sql.DB, dbName, tableName, dbType string) ([]string, erro
 }
 
 type ColumnInfo struct {
-	Name       string
-	DataType   string
-	IsNullable bool
-	IsPrimary  bool
-	Default    string
-	MaxLength  string
+	Name        string
+	DataType    string
+	IsNullable  bool
+	IsPrimary   bool
+	Default     string
+	MaxLength   string
+	Precision   string // Numeric precision, empty for non-numeric types
+	Scale       string
+	Collation   string
+	IsIdentity  bool // Identity or auto-increment
+	IsGenerated bool // Generated or computed column
 }
 
 func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo, error) {
@@ -188,7 +377,12 @@ func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo,
 				c.IS_NULLABLE,
 				CASE WHEN kcu.COLUMN_NAME IS NOT NULL THEN 'YES' ELSE 'NO' END as IS_PRIMARY,
 				COALESCE(c.COLUMN_DEFAULT, ''),
-				COALESCE(c.CHARACTER_MAXIMUM_LENGTH, '')
+				COALESCE(c.CHARACTER_MAXIMUM_LENGTH, ''),
+				COALESCE(c.NUMERIC_PRECISION, ''),
+				COALESCE(c.NUMERIC_SCALE, ''),
+				COALESCE(c.COLLATION_NAME, ''),
+				CASE WHEN c.EXTRA LIKE '%%auto_increment%%' THEN 'YES' ELSE 'NO' END,
+				CASE WHEN c.EXTRA LIKE '%%VIRTUAL GENERATED%%' OR c.EXTRA LIKE '%%STORED GENERATED%%' THEN 'YES' ELSE 'NO' END
 			FROM INFORMATION_SCHEMA.COLUMNS c
 			LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
 				ON c.TABLE_SCHEMA = kcu.TABLE_SCHEMA
@@ -198,6 +392,7 @@ func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo,
 			WHERE c.TABLE_SCHEMA = '%s' AND c.TABLE_NAME = '%s'
 			ORDER BY c.ORDINAL_POSITION`, dbName, tableName)
 	case "postgres":
+		schema, table := SplitSchemaTable(tableName, dbType)
 		query = fmt.Sprintf(`
 			SELECT
 				c.column_name,
@@ -205,17 +400,23 @@ func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo,
 				c.is_nullable,
 				CASE WHEN pk.column_name IS NOT NULL THEN 'YES' ELSE 'NO' END as is_primary,
 				COALESCE(c.column_default, ''),
-				COALESCE(c.character_maximum_length::text, '')
+				COALESCE(c.character_maximum_length::text, ''),
+				COALESCE(c.numeric_precision::text, ''),
+				COALESCE(c.numeric_scale::text, ''),
+				COALESCE(c.collation_name, ''),
+				CASE WHEN c.is_identity = 'YES' OR c.column_default LIKE 'nextval(%%' THEN 'YES' ELSE 'NO' END,
+				CASE WHEN c.is_generated = 'ALWAYS' THEN 'YES' ELSE 'NO' END
 			FROM information_schema.columns c
 			LEFT JOIN (
 				SELECT kcu.column_name
 				FROM information_schema.table_constraints tc
 				JOIN information_schema.key_column_usage kcu
 					ON tc.constraint_name = kcu.constraint_name
-				WHERE tc.table_name = '%s' AND tc.constraint_type = 'PRIMARY KEY'
+					AND tc.table_schema = kcu.table_schema
+				WHERE tc.table_schema = '%s' AND tc.table_name = '%s' AND tc.constraint_type = 'PRIMARY KEY'
 			) pk ON c.column_name = pk.column_name
-			WHERE c.table_name = '%s'
-			ORDER BY c.ordinal_position`, tableName, tableName)
+			WHERE c.table_schema = '%s' AND c.table_name = '%s'
+			ORDER BY c.ordinal_position`, schema, table, schema, table)
 	case "sqlserver":
 		cleanTable := CleanTableName(tableName, dbType)
 		schema := ExtractSchema(tableName, dbType)
@@ -226,7 +427,12 @@ func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo,
 				c.IS_NULLABLE,
 				CASE WHEN pk.COLUMN_NAME IS NOT NULL THEN 'YES' ELSE 'NO' END as IS_PRIMARY,
 				COALESCE(c.COLUMN_DEFAULT, ''),
-				COALESCE(CAST(c.CHARACTER_MAXIMUM_LENGTH AS VARCHAR), '')
+				COALESCE(CAST(c.CHARACTER_MAXIMUM_LENGTH AS VARCHAR), ''),
+				COALESCE(CAST(c.NUMERIC_PRECISION AS VARCHAR), ''),
+				COALESCE(CAST(c.NUMERIC_SCALE AS VARCHAR), ''),
+				COALESCE(c.COLLATION_NAME, ''),
+				CASE WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_CATALOG) + '.' + QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsIdentity') = 1 THEN 'YES' ELSE 'NO' END,
+				CASE WHEN COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.TABLE_CATALOG) + '.' + QUOTENAME(c.TABLE_SCHEMA) + '.' + QUOTENAME(c.TABLE_NAME)), c.COLUMN_NAME, 'IsComputed') = 1 THEN 'YES' ELSE 'NO' END
 			FROM [%s].INFORMATION_SCHEMA.COLUMNS c
 			LEFT JOIN (
 				SELECT kcu.COLUMN_NAME
@@ -248,11 +454,14 @@ func GetColumnInfo(db *sql.DB, dbName, tableName, dbType string) ([]ColumnInfo,
 	var columns []ColumnInfo
 	for rows.Next() {
 		var col ColumnInfo
-		var nullable, primary, maxLen string
-		rows.Scan(&col.Name, &col.DataType, &nullable, &primary, &col.Default, &maxLen)
+		var nullable, primary, maxLen, identity, generated string
+		rows.Scan(&col.Name, &col.DataType, &nullable, &primary, &col.Default, &maxLen,
+			&col.Precision, &col.Scale, &col.Collation, &identity, &generated)
 		col.IsNullable = nullable == "YES"
 		col.IsPrimary = primary == "YES"
 		col.MaxLength = maxLen
+		col.IsIdentity = identity == "YES"
+		col.IsGenerated = generated == "YES"
 		columns = append(columns, col)
 	}
 	return columns, nil
@@ -274,23 +483,21 @@ func GetReferencingFKs(db *sql.DB, dbName, tableName, dbType string) ([]FKDepend
 		query = fmt.Sprintf(`
 			SELECT TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_COLUMN_NAME
 			FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
-			WHERE REFERENCED_TABLE_SCHEMA = '%s' AND REFERENCED_TABLE_NAME = '%s'`, dbName, tableName)
+			WHERE REFERENCED_TABLE_SCHEMA = '%s' AND REFERENCED_TABLE_NAME = '%s'
+			ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`, dbName, tableName)
 	case "postgres":
+		// Unnest the key arrays together so composite FKs pair each child
+		// column with the column it references, in key order
 		query = fmt.Sprintf(`
-			SELECT
-				kcu.table_name,
-				kcu.column_name,
-				tc.constraint_name,
-				ccu.column_name AS referenced_column
-			FROM information_schema.table_constraints tc
-			JOIN information_schema.key_column_usage kcu
-				ON tc.constraint_name = kcu.constraint_name
-				AND tc.table_schema = kcu.table_schema
-			JOIN information_schema.constraint_column_usage ccu
-				ON ccu.constraint_name = tc.constraint_name
-				AND ccu.table_schema = tc.table_schema
-			WHERE tc.constraint_type = 'FOREIGN KEY'
-				AND ccu.table_name = '%s'`, tableName)
+			SELECT n.nspname || '.' || cl.relname, a.attname, c.conname, fa.attname
+			FROM pg_constraint c
+			JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, fattnum, ord) ON true
+			JOIN pg_class cl ON cl.oid = c.conrelid
+			JOIN pg_namespace n ON n.oid = cl.relnamespace
+			JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+			JOIN pg_attribute fa ON fa.attrelid = c.confrelid AND fa.attnum = k.fattnum
+			WHERE c.contype = 'f' AND c.confrelid = '%s'::regclass
+			ORDER BY 1, c.conname, k.ord`, pgRegclass(tableName))
 	case "sqlserver":
 		cleanTable := CleanTableName(tableName, dbType)
 		schema := ExtractSchema(tableName, dbType)
@@ -305,7 +512,9 @@ func GetReferencingFKs(db *sql.DB, dbName, tableName, dbType string) ([]FKDepend
 				ON fk.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
 			JOIN [%s].INFORMATION_SCHEMA.KEY_COLUMN_USAGE pk
 				ON pk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
-			WHERE pk.TABLE_SCHEMA = '%s' AND pk.TABLE_NAME = '%s'`, dbName, dbName, dbName, schema, cleanTable)
+				AND pk.ORDINAL_POSITION = fk.ORDINAL_POSITION
+			WHERE pk.TABLE_SCHEMA = '%s' AND pk.TABLE_NAME = '%s'
+			ORDER BY fk.TABLE_NAME, fk.CONSTRAINT_NAME, fk.ORDINAL_POSITION`, dbName, dbName, dbName, schema, cleanTable)
 	}
 
 	rows, err := db.Query(query)
@@ -323,36 +532,395 @@ func GetReferencingFKs(db *sql.DB, dbName, tableName, dbType string) ([]FKDepend
 	return deps, nil
 }
 
-func RunQuery(db *sql.DB, query string) (*QueryResult, error) {
+// FKReference represents a foreign key on a table that points at a parent table
+type FKReference struct {
+	ColumnName       string // Column in the table holding the FK
+	ConstraintName   string // Name of the FK constraint
+	ReferencedTable  string // Parent table the FK points at
+	ReferencedColumn string // Column in the parent table
+}
+
+// GetOutgoingFKs returns all foreign keys defined on the given table
+func GetOutgoingFKs(db *sql.DB, dbName, tableName, dbType string) ([]FKReference, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+			FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s' AND REFERENCED_TABLE_NAME IS NOT NULL
+			ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION`, dbName, tableName)
+	case "postgres":
+		// Unnest the key arrays together so composite FKs pair each local
+		// column with its referenced column, in key order
+		query = fmt.Sprintf(`
+			SELECT a.attname, c.conname, fn.nspname || '.' || fc.relname, fa.attname
+			FROM pg_constraint c
+			JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, fattnum, ord) ON true
+			JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+			JOIN pg_class fc ON fc.oid = c.confrelid
+			JOIN pg_namespace fn ON fn.oid = fc.relnamespace
+			JOIN pg_attribute fa ON fa.attrelid = c.confrelid AND fa.attnum = k.fattnum
+			WHERE c.contype = 'f' AND c.conrelid = '%s'::regclass
+			ORDER BY c.conname, k.ord`, pgRegclass(tableName))
+	case "sqlserver":
+		cleanTable := CleanTableName(tableName, dbType)
+		schema := ExtractSchema(tableName, dbType)
+		query = fmt.Sprintf(`
+			SELECT
+				fk.COLUMN_NAME,
+				fk.CONSTRAINT_NAME,
+				pk.TABLE_SCHEMA + '.' + pk.TABLE_NAME AS REFERENCED_TABLE_NAME,
+				pk.COLUMN_NAME AS REFERENCED_COLUMN_NAME
+			FROM [%s].INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS rc
+			JOIN [%s].INFORMATION_SCHEMA.KEY_COLUMN_USAGE fk
+				ON fk.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
+			JOIN [%s].INFORMATION_SCHEMA.KEY_COLUMN_USAGE pk
+				ON pk.CONSTRAINT_NAME = rc.UNIQUE_CONSTRAINT_NAME
+				AND pk.ORDINAL_POSITION = fk.ORDINAL_POSITION
+			WHERE fk.TABLE_SCHEMA = '%s' AND fk.TABLE_NAME = '%s'
+			ORDER BY fk.CONSTRAINT_NAME, fk.ORDINAL_POSITION`, dbName, dbName, dbName, schema, cleanTable)
+	}
+
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	columns, _ := rows.Columns()
-	result := &QueryResult{Columns: columns}
+	var refs []FKReference
+	for rows.Next() {
+		var ref FKReference
+		rows.Scan(&ref.ColumnName, &ref.ConstraintName, &ref.ReferencedTable, &ref.ReferencedColumn)
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
 
+// IndexInfo represents a secondary (non primary key) index on a table
+type IndexInfo struct {
+	Name    string
+	Columns []string // In key order
+	Unique  bool
+	Type    string // Access method, e.g. BTREE, btree, gin, NONCLUSTERED
+}
+
+// GetIndexes returns the secondary indexes defined on the given table
+func GetIndexes(db *sql.DB, dbName, tableName, dbType string) ([]IndexInfo, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT INDEX_NAME, CASE WHEN NON_UNIQUE = 0 THEN 'YES' ELSE 'NO' END, INDEX_TYPE, COLUMN_NAME
+			FROM INFORMATION_SCHEMA.STATISTICS
+			WHERE TABLE_SCHEMA = '%s' AND TABLE_NAME = '%s' AND INDEX_NAME <> 'PRIMARY'
+			ORDER BY INDEX_NAME, SEQ_IN_INDEX`, dbName, tableName)
+	case "postgres":
+		query = fmt.Sprintf(`
+			SELECT i.relname, CASE WHEN ix.indisunique THEN 'YES' ELSE 'NO' END, am.amname, a.attname
+			FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_am am ON am.oid = i.relam
+			JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+			JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+			WHERE ix.indrelid = '%s'::regclass AND NOT ix.indisprimary
+			ORDER BY i.relname, k.ord`, pgRegclass(tableName))
+	case "sqlserver":
+		cleanTable := CleanTableName(tableName, dbType)
+		schema := ExtractSchema(tableName, dbType)
+		query = PrependUseDatabase(fmt.Sprintf(`
+			SELECT i.name, CASE WHEN i.is_unique = 1 THEN 'YES' ELSE 'NO' END, i.type_desc, c.name
+			FROM sys.indexes i
+			JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+			JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+			WHERE i.object_id = OBJECT_ID('[%s].[%s]') AND i.is_primary_key = 0
+				AND i.type > 0 AND ic.is_included_column = 0
+			ORDER BY i.name, ic.key_ordinal`, schema, cleanTable), dbName, dbType)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
 	for rows.Next() {
-		values := make([]interface{}, len(columns))
-		pointers := make([]interface{}, len(columns))
-		for i := range values {
-			pointers[i] = &values[i]
+		var name, unique, indexType, col string
+		rows.Scan(&name, &unique, &indexType, &col)
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, col)
+			continue
 		}
-		rows.Scan(pointers...)
-
-		row := make([]string, len(columns))
-		for i, v := range values {
-			if v == nil {
-				row[i] = "NULL"
-			} else if b, ok := v.([]byte); ok {
-				row[i] = string(b)
-			} else {
-				row[i] = fmt.Sprintf("%v", v)
+		indexes = append(indexes, IndexInfo{Name: name, Columns: []string{col}, Unique: unique == "YES", Type: indexType})
+	}
+	return indexes, nil
+}
+
+// ConstraintInfo represents a UNIQUE or CHECK constraint on a table
+type ConstraintInfo struct {
+	Name       string
+	Type       string   // UNIQUE or CHECK
+	Columns    []string // Constrained columns, may be empty for table-level checks
+	Definition string   // Check expression, empty for UNIQUE
+}
+
+// GetConstraints returns the unique and check constraints defined on the given table
+func GetConstraints(db *sql.DB, dbName, tableName, dbType string) ([]ConstraintInfo, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT tc.CONSTRAINT_NAME, tc.CONSTRAINT_TYPE, COALESCE(kcu.COLUMN_NAME, ''), COALESCE(cc.CHECK_CLAUSE, '')
+			FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc
+			LEFT JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu
+				ON kcu.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+				AND kcu.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+				AND kcu.TABLE_NAME = tc.TABLE_NAME
+			LEFT JOIN INFORMATION_SCHEMA.CHECK_CONSTRAINTS cc
+				ON cc.CONSTRAINT_SCHEMA = tc.CONSTRAINT_SCHEMA
+				AND cc.CONSTRAINT_NAME = tc.CONSTRAINT_NAME
+			WHERE tc.TABLE_SCHEMA = '%s' AND tc.TABLE_NAME = '%s' AND tc.CONSTRAINT_TYPE IN ('UNIQUE', 'CHECK')
+			ORDER BY tc.CONSTRAINT_NAME, kcu.ORDINAL_POSITION`, dbName, tableName)
+	case "postgres":
+		query = fmt.Sprintf(`
+			SELECT c.conname, CASE c.contype WHEN 'u' THEN 'UNIQUE' ELSE 'CHECK' END,
+				COALESCE(a.attname, ''), CASE c.contype WHEN 'c' THEN pg_get_constraintdef(c.oid) ELSE '' END
+			FROM pg_constraint c
+			LEFT JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord) ON true
+			LEFT JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+			WHERE c.conrelid = '%s'::regclass AND c.contype IN ('u', 'c')
+			ORDER BY c.conname, k.ord`, pgRegclass(tableName))
+	case "sqlserver":
+		cleanTable := CleanTableName(tableName, dbType)
+		schema := ExtractSchema(tableName, dbType)
+		query = PrependUseDatabase(fmt.Sprintf(`
+			SELECT name, type, col, definition FROM (
+				SELECT kc.name, 'UNIQUE' AS type, c.name AS col, '' AS definition, ic.key_ordinal AS ord
+				FROM sys.key_constraints kc
+				JOIN sys.index_columns ic ON ic.object_id = kc.parent_object_id AND ic.index_id = kc.unique_index_id
+				JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+				WHERE kc.parent_object_id = OBJECT_ID('[%s].[%s]') AND kc.type = 'UQ'
+				UNION ALL
+				SELECT cc.name, 'CHECK', COALESCE(c.name, ''), cc.definition, 0
+				FROM sys.check_constraints cc
+				LEFT JOIN sys.columns c ON c.object_id = cc.parent_object_id AND c.column_id = cc.parent_column_id
+				WHERE cc.parent_object_id = OBJECT_ID('[%s].[%s]')
+			) x
+			ORDER BY name, ord`, schema, cleanTable, schema, cleanTable), dbName, dbType)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var constraints []ConstraintInfo
+	for rows.Next() {
+		var c ConstraintInfo
+		var col string
+		rows.Scan(&c.Name, &c.Type, &col, &c.Definition)
+		if n := len(constraints); n > 0 && constraints[n-1].Name == c.Name {
+			if col != "" {
+				constraints[n-1].Columns = append(constraints[n-1].Columns, col)
 			}
+			continue
+		}
+		if col != "" {
+			c.Columns = []string{col}
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// ValueCount is a value and the number of rows holding it
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+// ColumnStats summarizes the values held in a single column
+type ColumnStats struct {
+	Column    string
+	Total     int
+	Distinct  int
+	Nulls     int
+	Min       string
+	Max       string
+	Avg       string // Empty for non-numeric columns
+	MinLength int
+	MaxLength int
+	TopValues []ValueCount
+}
+
+// ProfileColumn computes ColumnStats for a column across the whole table using
+// aggregate queries. numeric controls whether an average is calculated.
+func ProfileColumn(db *sql.DB, dbName, tableName, column, dbType string, numeric bool, topN int) (*ColumnStats, error) {
+	col := QuoteIdent(column, dbType)
+	table := FormatTableName(tableName, dbType)
+	var asText, length, avg string
+	switch dbType {
+	case "mysql":
+		asText = "CAST(%s AS CHAR)"
+		length = "CHAR_LENGTH(CAST(%s AS CHAR))"
+		avg = "CAST(AVG(%s) AS CHAR)"
+	case "postgres":
+		asText = "CAST(%s AS TEXT)"
+		length = "LENGTH(CAST(%s AS TEXT))"
+		avg = "CAST(AVG(%s) AS TEXT)"
+	case "sqlserver":
+		table = fmt.Sprintf("[%s].[%s].[%s]", dbName, ExtractSchema(tableName, dbType), CleanTableName(tableName, dbType))
+		asText = "CAST(%s AS NVARCHAR(MAX))"
+		length = "LEN(CAST(%s AS NVARCHAR(MAX)))"
+		avg = "CAST(AVG(CAST(%s AS FLOAT)) AS NVARCHAR(100))"
+	}
+	text := fmt.Sprintf(asText, col)
+	avgExpr := "NULL"
+	if numeric {
+		avgExpr = fmt.Sprintf(avg, col)
+	}
+
+	// MIN/MAX are taken on the native type so numbers and dates order correctly
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(DISTINCT %s),
+			COUNT(*) - COUNT(%s),
+			%s,
+			%s,
+			%s,
+			COALESCE(MIN(%s), 0),
+			COALESCE(MAX(%s), 0)
+		FROM %s`,
+		col, col,
+		fmt.Sprintf(asText, "MIN("+col+")"), fmt.Sprintf(asText, "MAX("+col+")"), avgExpr,
+		fmt.Sprintf(length, col), fmt.Sprintf(length, col), table)
+
+	stats := &ColumnStats{Column: column}
+	var minVal, maxVal, avgVal sql.NullString
+	err := db.QueryRow(query).Scan(&stats.Total, &stats.Distinct, &stats.Nulls,
+		&minVal, &maxVal, &avgVal, &stats.MinLength, &stats.MaxLength)
+	if err != nil {
+		return nil, err
+	}
+	stats.Min, stats.Max, stats.Avg = minVal.String, maxVal.String, avgVal.String
+
+	var topQuery string
+	switch dbType {
+	case "sqlserver":
+		topQuery = fmt.Sprintf("SELECT TOP %d %s, COUNT(*) FROM %s GROUP BY %s ORDER BY COUNT(*) DESC",
+			topN, text, table, text)
+	default:
+		topQuery = fmt.Sprintf("SELECT %s, COUNT(*) FROM %s GROUP BY %s ORDER BY COUNT(*) DESC LIMIT %d",
+			text, table, text, topN)
+	}
+
+	rows, err := db.Query(topQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v sql.NullString
+		var vc ValueCount
+		rows.Scan(&v, &vc.Count)
+		vc.Value = v.String
+		if !v.Valid {
+			vc.Value = "NULL"
 		}
-		result.Rows = append(result.Rows, row)
+		stats.TopValues = append(stats.TopValues, vc)
+	}
+	return stats, nil
+}
+
+// Exec runs a statement that returns no rows and reports how many rows it
+// affected, or -1 when the driver cannot tell
+func Exec(db *sql.DB, query string, args ...any) (int64, error) {
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return -1, nil
+	}
+	return affected, nil
+}
+
+// ExecExpecting runs a write statement in a transaction and commits it only
+// if it affects exactly expected rows, so a confirmation based on an earlier
+// COUNT cannot be applied to a different set of rows. It returns the number
+// of rows the statement affected.
+func ExecExpecting(db *sql.DB, dbName, query, dbType string, expected int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// USE is run separately so RowsAffected belongs to the statement
+	if dbType == "sqlserver" && dbName != "" {
+		if _, err := tx.Exec(fmt.Sprintf("USE [%s]", dbName)); err != nil {
+			return 0, err
+		}
+	}
+	res, err := tx.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected != expected {
+		return affected, fmt.Errorf("statement would affect %d rows but %d were confirmed; rolled back", affected, expected)
+	}
+	return affected, tx.Commit()
+}
+
+func RunQuery(db *sql.DB, query string, args ...any) (*QueryResult, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	result := &QueryResult{Columns: columns, ColumnTypes: make([]string, len(columns))}
+	if types, err := rows.ColumnTypes(); err == nil {
+		for i, t := range types {
+			result.ColumnTypes[i] = t.DatabaseTypeName()
+		}
+	}
+
+	for rows.Next() {
+		result.Rows = append(result.Rows, scanRow(rows, len(columns)))
 	}
 
 	return result, nil
 }
+
+// scanRow scans the current row into strings, rendering NULL as "NULL"
+func scanRow(rows *sql.Rows, n int) []string {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	rows.Scan(pointers...)
+
+	row := make([]string, n)
+	for i, v := range values {
+		if v == nil {
+			row[i] = "NULL"
+		} else if b, ok := v.([]byte); ok {
+			row[i] = string(b)
+		} else {
+			row[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return row
+}
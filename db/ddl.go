@@ -0,0 +1,180 @@
+// ddl.go renders DDL from catalog metadata: column definitions, CREATE TABLE
+// statements and the ALTER statements used by schema migration scripts.
+// MySQL's native SHOW CREATE TABLE is used where available.
+package db
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// GetTableDDL returns the CREATE TABLE statement for a table, including its
+// indexes. MySQL reports it natively; other engines are reconstructed from the
+// catalog.
+func GetTableDDL(db *sql.DB, dbName, tableName, dbType string) (string, error) {
+	if dbType == "mysql" {
+		var name, ddl string
+		query := "SHOW CREATE TABLE " + QualifiedTableName(dbName, tableName, dbType)
+		if err := db.QueryRow(query).Scan(&name, &ddl); err != nil {
+			return "", err
+		}
+		return ddl + ";", nil
+	}
+
+	ts, err := LoadTableSchema(TableSide{DB: db, DBName: dbName, DBType: dbType}, tableName)
+	if err != nil {
+		return "", err
+	}
+	if len(ts.Columns) == 0 {
+		return "", fmt.Errorf("table %s not found", tableName)
+	}
+	return BuildCreateTable(ts, dbType), nil
+}
+
+// ColumnTypeSQL renders a column's type including its length, or precision
+// and scale for exact numeric types
+func ColumnTypeSQL(col ColumnInfo) string {
+	switch col.MaxLength {
+	case "":
+		dataType := strings.ToLower(col.DataType)
+		if (dataType == "decimal" || dataType == "numeric") && col.Precision != "" {
+			return fmt.Sprintf("%s(%s,%s)", col.DataType, col.Precision, cmp.Or(col.Scale, "0"))
+		}
+		return col.DataType
+	case "-1":
+		return col.DataType + "(MAX)"
+	}
+	return fmt.Sprintf("%s(%s)", col.DataType, col.MaxLength)
+}
+
+// ColumnDefinitionSQL renders "name type [NOT NULL] [DEFAULT x]" plus the
+// engine's identity clause for identity/auto-increment columns
+func ColumnDefinitionSQL(col ColumnInfo, dbType string) string {
+	def := QuoteIdent(col.Name, dbType) + " " + ColumnTypeSQL(col)
+	if !col.IsNullable {
+		def += " NOT NULL"
+	}
+	if col.Default != "" {
+		def += " DEFAULT " + col.Default
+	}
+	if col.IsIdentity {
+		switch dbType {
+		case "mysql":
+			def += " AUTO_INCREMENT"
+		case "sqlserver":
+			def += " IDENTITY(1,1)"
+		case "postgres":
+			// Serial columns already carry a nextval() default
+			if col.Default == "" {
+				def += " GENERATED BY DEFAULT AS IDENTITY"
+			}
+		}
+	}
+	return def
+}
+
+// BuildCreateTable reconstructs a CREATE TABLE statement from catalog metadata
+func BuildCreateTable(ts *TableSchema, dbType string) string {
+	var lines []string
+	for _, col := range ts.Columns {
+		lines = append(lines, "  "+ColumnDefinitionSQL(col, dbType))
+	}
+	if len(ts.PK) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", quoteIdents(ts.PK, dbType)))
+	}
+	for _, fk := range groupForeignKeys(ts.FKs) {
+		lines = append(lines, "  "+fk.sql(dbType))
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n%s\n);", FormatTableName(ts.Name, dbType), strings.Join(lines, ",\n"))
+
+	for _, idx := range ts.Indexes {
+		unique := ""
+		if idx.Unique {
+			unique = "UNIQUE "
+		}
+		ddl += fmt.Sprintf("\n\nCREATE %sINDEX %s ON %s (%s);", unique,
+			QuoteIdent(idx.Name, dbType), FormatTableName(ts.Name, dbType), quoteIdents(idx.Columns, dbType))
+	}
+	return ddl
+}
+
+// foreignKey is one FK constraint, combining the per-column rows returned by
+// GetOutgoingFKs in key order
+type foreignKey struct {
+	name              string
+	columns           []string
+	referencedTable   string
+	referencedColumns []string
+}
+
+// groupForeignKeys combines FK rows by constraint name, keeping the order in
+// which constraints first appear
+func groupForeignKeys(refs []FKReference) []foreignKey {
+	var fks []foreignKey
+	index := make(map[string]int)
+	for _, ref := range refs {
+		i, ok := index[ref.ConstraintName]
+		if !ok {
+			i = len(fks)
+			index[ref.ConstraintName] = i
+			fks = append(fks, foreignKey{name: ref.ConstraintName, referencedTable: ref.ReferencedTable})
+		}
+		fks[i].columns = append(fks[i].columns, ref.ColumnName)
+		fks[i].referencedColumns = append(fks[i].referencedColumns, ref.ReferencedColumn)
+	}
+	return fks
+}
+
+// String describes the constraint for reports, e.g. org_id -> orgs.id or
+// (a, b) -> parent (x, y)
+func (fk foreignKey) String() string {
+	if len(fk.columns) == 1 {
+		return fk.columns[0] + " -> " + fk.referencedTable + "." + fk.referencedColumns[0]
+	}
+	return fmt.Sprintf("(%s) -> %s (%s)", strings.Join(fk.columns, ", "), fk.referencedTable, strings.Join(fk.referencedColumns, ", "))
+}
+
+func (fk foreignKey) sql(dbType string) string {
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+		QuoteIdent(fk.name, dbType), quoteIdents(fk.columns, dbType),
+		FormatTableName(fk.referencedTable, dbType), quoteIdents(fk.referencedColumns, dbType))
+}
+
+func quoteIdents(names []string, dbType string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = QuoteIdent(name, dbType)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// alterColumnSQL renders the statements that change a column to match col
+func alterColumnSQL(table string, col ColumnInfo, dbType string) []string {
+	name := QuoteIdent(col.Name, dbType)
+	switch dbType {
+	case "mysql":
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s;", table, ColumnDefinitionSQL(col, dbType))}
+	case "postgres":
+		stmts := []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", table, name, ColumnTypeSQL(col))}
+		if col.IsNullable {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, name))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, name))
+		}
+		if col.Default != "" {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, name, col.Default))
+		} else {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, name))
+		}
+		return stmts
+	default:
+		null := "NULL"
+		if !col.IsNullable {
+			null = "NOT NULL"
+		}
+		// SQL Server defaults are separate constraints and are left for review
+		return []string{fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s;", table, name, ColumnTypeSQL(col), null)}
+	}
+}
@@ -0,0 +1,106 @@
+// expand.go substitutes ${ENV_VAR} and ${file:/path} references in
+// connection strings, so saved connections can hold a template and pick up
+// secrets when connecting. Errors name the variable or file but never the
+// value it expanded to.
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ExpandConnString replaces ${NAME} with the environment variable NAME and
+// ${file:/path} with the contents of the file minus its trailing newline. A
+// path starting with ~/ is relative to the home directory. Values are
+// inserted as is, so they must already be escaped for the URL or DSN.
+func ExpandConnString(connString string) (string, error) {
+	expanded, _, err := expandConnString(connString)
+	return expanded, err
+}
+
+// expandConnString expands connString and also returns the substituted
+// values so they can be redacted from error messages
+func expandConnString(connString string) (string, []string, error) {
+	var b strings.Builder
+	var values []string
+	rest := connString
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			return "", nil, errors.New("unterminated ${ in connection string")
+		}
+		value, err := lookupReference(rest[start+2 : start+end])
+		if err != nil {
+			return "", nil, err
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(value)
+		values = append(values, value)
+		rest = rest[start+end+1:]
+	}
+	return b.String(), values, nil
+}
+
+func lookupReference(ref string) (string, error) {
+	if path, ok := strings.CutPrefix(ref, "file:"); ok {
+		if path == "" {
+			return "", errors.New("${file:} needs a path")
+		}
+		if rel, ok := strings.CutPrefix(path, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			path = filepath.Join(home, rel)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// PathError only holds the path and the reason
+			return "", fmt.Errorf("cannot read ${file:%s}: %w", strings.TrimPrefix(ref, "file:"), errors.Unwrap(err))
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if !envNameRegex.MatchString(ref) {
+		return "", fmt.Errorf("invalid reference ${%s}: expected ${ENV_VAR} or ${file:/path}", ref)
+	}
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// redact masks expanded values, raw or URL-escaped, in msg
+func redact(msg string, values []string) string {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		for _, form := range []string{v, url.PathEscape(v), url.QueryEscape(v)} {
+			msg = strings.ReplaceAll(msg, form, "****")
+		}
+	}
+	return msg
+}
+
+// redactError returns err, or a copy of its message with expanded values
+// masked when it contains any
+func redactError(err error, values []string) error {
+	if msg := redact(err.Error(), values); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
@@ -0,0 +1,391 @@
+// explain.go runs the dialect's plan command for a query and parses the
+// result into a common tree: Postgres EXPLAIN (FORMAT JSON), MySQL
+// EXPLAIN FORMAT=JSON and SQL Server SET SHOWPLAN_XML.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PlanNode is one operator in a query plan. Cost is the engine's cumulative
+// estimate for the node and its children; ActualTime is likewise cumulative,
+// in milliseconds across all loops, and only set for analyzed plans.
+type PlanNode struct {
+	Label      string
+	Cost       float64
+	Rows       float64 // Estimated rows
+	ActualRows float64
+	ActualTime float64
+	HasActual  bool
+	Details    []string
+	Children   []*PlanNode
+}
+
+// SelfCost returns the node's cost excluding its children
+func (n *PlanNode) SelfCost() float64 {
+	cost := n.Cost
+	for _, c := range n.Children {
+		cost -= c.Cost
+	}
+	return max(cost, 0)
+}
+
+// SelfTime returns the node's actual time excluding its children
+func (n *PlanNode) SelfTime() float64 {
+	t := n.ActualTime
+	for _, c := range n.Children {
+		t -= c.ActualTime
+	}
+	return max(t, 0)
+}
+
+// Walk calls fn for the node and all of its descendants, depth first
+func (n *PlanNode) Walk(fn func(*PlanNode)) {
+	fn(n)
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Explain returns the plan for query. analyze executes the query to collect
+// actual rows and times; it is only supported on Postgres and runs inside a
+// transaction that is rolled back so writes are not kept.
+func Explain(db *sql.DB, dbName, query, dbType string, analyze bool, args ...any) (*PlanNode, error) {
+	switch dbType {
+	case "postgres":
+		return explainPostgres(db, query, analyze, args)
+	case "mysql":
+		var plan string
+		if err := db.QueryRow("EXPLAIN FORMAT=JSON "+query, args...).Scan(&plan); err != nil {
+			return nil, err
+		}
+		return ParseMySQLPlan([]byte(plan))
+	case "sqlserver":
+		return explainSQLServer(db, dbName, query, args)
+	}
+	return nil, fmt.Errorf("unsupported database type: %s", dbType)
+}
+
+func explainPostgres(db *sql.DB, query string, analyze bool, args []any) (*PlanNode, error) {
+	options := "FORMAT JSON"
+	if analyze {
+		options += ", ANALYZE"
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var plan string
+	if err := tx.QueryRow(fmt.Sprintf("EXPLAIN (%s) %s", options, query), args...).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return ParsePostgresPlan([]byte(plan))
+}
+
+// explainSQLServer pins a single connection because SHOWPLAN_XML is a session
+// setting that must be enabled in its own batch
+func explainSQLServer(db *sql.DB, dbName, query string, args []any) (*PlanNode, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if dbName != "" {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("USE [%s]", dbName)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, "SET SHOWPLAN_XML ON"); err != nil {
+		return nil, err
+	}
+	defer conn.ExecContext(ctx, "SET SHOWPLAN_XML OFF")
+
+	var plan string
+	if err := conn.QueryRowContext(ctx, query, args...).Scan(&plan); err != nil {
+		return nil, err
+	}
+	return ParseShowplanXML([]byte(plan))
+}
+
+// ParsePostgresPlan parses the output of EXPLAIN (FORMAT JSON)
+func ParsePostgresPlan(data []byte) (*PlanNode, error) {
+	var doc []struct {
+		Plan          map[string]any `json:"Plan"`
+		ExecutionTime *float64       `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	if len(doc) == 0 || doc[0].Plan == nil {
+		return nil, fmt.Errorf("parse plan: no plan returned")
+	}
+	return postgresNode(doc[0].Plan), nil
+}
+
+var postgresDetailKeys = []string{"Join Type", "Index Cond", "Hash Cond", "Merge Cond", "Filter",
+	"Rows Removed by Filter", "Sort Key", "Group Key", "Strategy"}
+
+func postgresNode(m map[string]any) *PlanNode {
+	n := &PlanNode{Label: jsonString(m["Node Type"])}
+	if rel := jsonString(m["Relation Name"]); rel != "" {
+		n.Label += " on " + rel
+		if alias := jsonString(m["Alias"]); alias != "" && alias != rel {
+			n.Label += " " + alias
+		}
+	}
+	if idx := jsonString(m["Index Name"]); idx != "" {
+		n.Label += " using " + idx
+	}
+	n.Cost = jsonFloat(m["Total Cost"])
+	n.Rows = jsonFloat(m["Plan Rows"])
+	if t, ok := m["Actual Total Time"]; ok {
+		loops := max(jsonFloat(m["Actual Loops"]), 1)
+		n.HasActual = true
+		n.ActualTime = jsonFloat(t) * loops
+		n.ActualRows = jsonFloat(m["Actual Rows"]) * loops
+	}
+	for _, key := range postgresDetailKeys {
+		if v, ok := m[key]; ok {
+			n.Details = append(n.Details, key+": "+jsonString(v))
+		}
+	}
+	if plans, ok := m["Plans"].([]any); ok {
+		for _, p := range plans {
+			if child, ok := p.(map[string]any); ok {
+				n.Children = append(n.Children, postgresNode(child))
+			}
+		}
+	}
+	return n
+}
+
+// ParseMySQLPlan parses the output of EXPLAIN FORMAT=JSON. The document is a
+// tree of named operations (query_block, nested_loop, table, ...); every
+// nested object is treated as a child operation.
+func ParseMySQLPlan(data []byte) (*PlanNode, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	block, ok := doc["query_block"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("parse plan: no query_block")
+	}
+	return mysqlNode("query_block", block), nil
+}
+
+var mysqlDetailKeys = []string{"access_type", "key", "attached_condition", "using_filesort", "using_temporary_table"}
+
+func mysqlNode(name string, m map[string]any) *PlanNode {
+	n := &PlanNode{Label: name}
+	if table := jsonString(m["table_name"]); table != "" {
+		n.Label += " " + table
+		if access := jsonString(m["access_type"]); access != "" {
+			n.Label += " (" + access + ")"
+		}
+	}
+	n.Rows = jsonFloat(m["rows_produced_per_join"])
+	if n.Rows == 0 {
+		n.Rows = jsonFloat(m["rows_examined_per_scan"])
+	}
+	for _, key := range mysqlDetailKeys {
+		if v, ok := m[key]; ok {
+			n.Details = append(n.Details, key+": "+jsonString(v))
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		switch v := m[k].(type) {
+		case map[string]any:
+			if k != "cost_info" {
+				n.Children = append(n.Children, mysqlNode(k, v))
+			}
+		case []any:
+			// Arrays of operations (nested_loop, attached_subqueries, ...)
+			// become a node of their own; arrays of strings are skipped
+			group := &PlanNode{Label: k}
+			for _, item := range v {
+				obj, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				// nested_loop items wrap a single {"table": {...}}
+				if len(obj) == 1 {
+					for inner, val := range obj {
+						if child, ok := val.(map[string]any); ok {
+							group.Children = append(group.Children, mysqlNode(inner, child))
+						}
+					}
+					continue
+				}
+				group.Children = append(group.Children, mysqlNode(k, obj))
+			}
+			if len(group.Children) > 0 {
+				for _, c := range group.Children {
+					group.Cost += c.Cost
+				}
+				n.Children = append(n.Children, group)
+			}
+		}
+	}
+
+	// prefix_cost accumulates along the join order, so tables use their own
+	// read and evaluation cost and wrapper operations add up their children
+	cost, _ := m["cost_info"].(map[string]any)
+	switch {
+	case cost["query_cost"] != nil:
+		n.Cost = jsonFloat(cost["query_cost"])
+	case cost["read_cost"] != nil || cost["eval_cost"] != nil:
+		n.Cost = jsonFloat(cost["read_cost"]) + jsonFloat(cost["eval_cost"])
+	default:
+		n.Cost = jsonFloat(cost["sort_cost"])
+	}
+	for _, c := range n.Children {
+		if cost["query_cost"] == nil {
+			n.Cost += c.Cost
+		}
+	}
+	return n
+}
+
+// xmlElement is a generic XML element used to walk showplan documents
+type xmlElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Children []xmlElement `xml:",any"`
+}
+
+func (e xmlElement) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// ParseShowplanXML parses SQL Server SHOWPLAN_XML output. RelOp elements are
+// the plan operators; they nest inside operator-specific elements.
+func ParseShowplanXML(data []byte) (*PlanNode, error) {
+	var doc xmlElement
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	ops := findRelOps(doc)
+	switch len(ops) {
+	case 0:
+		return nil, fmt.Errorf("parse plan: no operators found")
+	case 1:
+		return relOpNode(ops[0]), nil
+	}
+	// Several statements: group them under a batch node
+	root := &PlanNode{Label: "Batch"}
+	for _, op := range ops {
+		child := relOpNode(op)
+		root.Cost += child.Cost
+		root.Children = append(root.Children, child)
+	}
+	return root, nil
+}
+
+// findRelOps returns the nearest RelOp descendants of e
+func findRelOps(e xmlElement) []xmlElement {
+	var ops []xmlElement
+	for _, c := range e.Children {
+		if c.XMLName.Local == "RelOp" {
+			ops = append(ops, c)
+		} else {
+			ops = append(ops, findRelOps(c)...)
+		}
+	}
+	return ops
+}
+
+// findObject returns the first Object element below e that is not inside a
+// nested RelOp
+func findObject(e xmlElement) (xmlElement, bool) {
+	for _, c := range e.Children {
+		if c.XMLName.Local == "RelOp" {
+			continue
+		}
+		if c.XMLName.Local == "Object" {
+			return c, true
+		}
+		if obj, ok := findObject(c); ok {
+			return obj, true
+		}
+	}
+	return xmlElement{}, false
+}
+
+func relOpNode(op xmlElement) *PlanNode {
+	n := &PlanNode{Label: op.attr("PhysicalOp")}
+	if logical := op.attr("LogicalOp"); logical != "" && logical != n.Label {
+		n.Label += " (" + logical + ")"
+	}
+	if obj, ok := findObject(op); ok {
+		name := strings.Trim(obj.attr("Table"), "[]")
+		if schema := strings.Trim(obj.attr("Schema"), "[]"); schema != "" {
+			name = schema + "." + name
+		}
+		n.Label += " on " + name
+		if idx := strings.Trim(obj.attr("Index"), "[]"); idx != "" {
+			n.Label += " using " + idx
+		}
+	}
+	n.Cost, _ = strconv.ParseFloat(op.attr("EstimatedTotalSubtreeCost"), 64)
+	n.Rows, _ = strconv.ParseFloat(op.attr("EstimateRows"), 64)
+	for _, key := range []string{"EstimateIO", "EstimateCPU", "EstimatedExecutionMode"} {
+		if v := op.attr(key); v != "" {
+			n.Details = append(n.Details, key+": "+v)
+		}
+	}
+	for _, child := range findRelOps(op) {
+		n.Children = append(n.Children, relOpNode(child))
+	}
+	return n
+}
+
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = jsonString(p)
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonFloat reads a number that MySQL may encode as a string
+func jsonFloat(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
@@ -0,0 +1,254 @@
+// grants.go reads users, roles, role memberships and table privileges from
+// each engine's catalogs (pg_roles and information_schema.table_privileges,
+// mysql.user and mysql.role_edges, sys.database_principals and
+// sys.database_permissions), and what the connected user may do on a table.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DBRole is a user or role. MemberOf lists the roles it has been granted.
+type DBRole struct {
+	Name      string
+	Kind      string // "user", "role" or "group"
+	Superuser bool
+	MemberOf  []string
+}
+
+// TablePrivilege is a privilege granted (or, on SQL Server, denied) on a
+// table. Scope is "table", "schema" or "database" for grants that apply to
+// the table through its container.
+type TablePrivilege struct {
+	Grantee   string
+	Privilege string
+	Scope     string
+	Grantable bool
+	Denied    bool
+}
+
+// TableAccess summarizes what the connected user may do on a table
+type TableAccess struct {
+	User   string
+	Select bool
+	Insert bool
+	Update bool
+	Delete bool
+}
+
+// Allowed returns the DML privileges the user holds, in a fixed order
+func (t TableAccess) Allowed() []string {
+	return t.filter(true)
+}
+
+// Missing returns the DML privileges the user lacks
+func (t TableAccess) Missing() []string {
+	return t.filter(false)
+}
+
+func (t TableAccess) filter(held bool) []string {
+	var privs []string
+	for _, p := range []struct {
+		name string
+		ok   bool
+	}{{"SELECT", t.Select}, {"INSERT", t.Insert}, {"UPDATE", t.Update}, {"DELETE", t.Delete}} {
+		if p.ok == held {
+			privs = append(privs, p.name)
+		}
+	}
+	return privs
+}
+
+// Summary describes the user's access in one line, e.g.
+// "alice can SELECT, UPDATE; cannot INSERT, DELETE"
+func (t TableAccess) Summary() string {
+	s := t.User + " can "
+	if allowed := t.Allowed(); len(allowed) > 0 {
+		s += strings.Join(allowed, ", ")
+	} else {
+		s += "nothing"
+	}
+	if missing := t.Missing(); len(missing) > 0 {
+		s += "; cannot " + strings.Join(missing, ", ")
+	}
+	return s
+}
+
+// ListRoles returns the server's users and roles with their memberships.
+// SQL Server lists the principals of dbName.
+func ListRoles(db *sql.DB, dbName, dbType string) ([]DBRole, error) {
+	var query string
+	switch dbType {
+	case "postgres":
+		query = `
+			SELECT r.rolname, CASE WHEN r.rolcanlogin THEN 'user' ELSE 'role' END, r.rolsuper,
+				COALESCE(string_agg(m.rolname, ',' ORDER BY m.rolname), '')
+			FROM pg_roles r
+			LEFT JOIN pg_auth_members am ON am.member = r.oid
+			LEFT JOIN pg_roles m ON m.oid = am.roleid
+			WHERE r.rolname NOT LIKE 'pg\_%'
+			GROUP BY r.rolname, r.rolcanlogin, r.rolsuper
+			ORDER BY r.rolname`
+	case "mysql":
+		// Roles are locked accounts without a password
+		query = `
+			SELECT CONCAT(u.User, '@', u.Host),
+				CASE WHEN u.account_locked = 'Y' AND u.authentication_string = '' THEN 'role' ELSE 'user' END,
+				u.Super_priv = 'Y',
+				COALESCE(GROUP_CONCAT(CONCAT(e.FROM_USER, '@', e.FROM_HOST) ORDER BY e.FROM_USER SEPARATOR ','), '')
+			FROM mysql.user u
+			LEFT JOIN mysql.role_edges e ON e.TO_USER = u.User AND e.TO_HOST = u.Host
+			WHERE u.User NOT LIKE 'mysql.%'
+			GROUP BY u.User, u.Host, u.account_locked, u.authentication_string, u.Super_priv
+			ORDER BY u.User, u.Host`
+	case "sqlserver":
+		query = PrependUseDatabase(`
+			SELECT p.name,
+				CASE WHEN p.type = 'R' THEN 'role' WHEN p.type IN ('G', 'X') THEN 'group' ELSE 'user' END,
+				CASE WHEN EXISTS (
+					SELECT 1 FROM sys.database_role_members o
+					WHERE o.member_principal_id = p.principal_id AND o.role_principal_id = USER_ID('db_owner')
+				) OR p.name = 'dbo' THEN 1 ELSE 0 END,
+				COALESCE(STUFF((
+					SELECT ',' + r.name FROM sys.database_role_members rm
+					JOIN sys.database_principals r ON r.principal_id = rm.role_principal_id
+					WHERE rm.member_principal_id = p.principal_id
+					ORDER BY r.name
+					FOR XML PATH('')), 1, 1, ''), '')
+			FROM sys.database_principals p
+			WHERE p.type IN ('S', 'U', 'G', 'R', 'E', 'X') AND p.name NOT IN ('sys', 'INFORMATION_SCHEMA', 'guest')
+			ORDER BY p.name`, dbName, dbType)
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []DBRole
+	for rows.Next() {
+		var r DBRole
+		var memberOf string
+		rows.Scan(&r.Name, &r.Kind, &r.Superuser, &memberOf)
+		if memberOf != "" {
+			r.MemberOf = strings.Split(memberOf, ",")
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// GetTablePrivileges returns the privileges granted on a table, including
+// schema- and database-wide grants on MySQL and SQL Server
+func GetTablePrivileges(db *sql.DB, dbName, tableName, dbType string) ([]TablePrivilege, error) {
+	var query string
+	switch dbType {
+	case "postgres":
+		schema, table := SplitSchemaTable(tableName, dbType)
+		query = fmt.Sprintf(`
+			SELECT grantee, privilege_type, 'table', is_grantable = 'YES', false
+			FROM information_schema.table_privileges
+			WHERE table_schema = '%s' AND table_name = '%s'
+			ORDER BY grantee, privilege_type`, schema, table)
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT GRANTEE, PRIVILEGE_TYPE, 'table', IS_GRANTABLE = 'YES', false
+			FROM information_schema.TABLE_PRIVILEGES
+			WHERE TABLE_SCHEMA = '%[1]s' AND TABLE_NAME = '%[2]s'
+			UNION ALL
+			SELECT GRANTEE, PRIVILEGE_TYPE, 'schema', IS_GRANTABLE = 'YES', false
+			FROM information_schema.SCHEMA_PRIVILEGES
+			WHERE TABLE_SCHEMA = '%[1]s'
+			ORDER BY 1, 2`, dbName, tableName)
+	case "sqlserver":
+		cleanTable := CleanTableName(tableName, dbType)
+		schema := ExtractSchema(tableName, dbType)
+		query = PrependUseDatabase(fmt.Sprintf(`
+			SELECT pr.name, p.permission_name,
+				CASE p.class WHEN 0 THEN 'database' WHEN 3 THEN 'schema' ELSE 'table' END,
+				CASE WHEN p.state = 'W' THEN 1 ELSE 0 END,
+				CASE WHEN p.state = 'D' THEN 1 ELSE 0 END
+			FROM sys.database_permissions p
+			JOIN sys.database_principals pr ON pr.principal_id = p.grantee_principal_id
+			WHERE (p.class = 1 AND p.major_id = OBJECT_ID('[%[1]s].[%[2]s]') AND p.minor_id = 0)
+				OR (p.class = 3 AND p.major_id = SCHEMA_ID('%[1]s'))
+				OR (p.class = 0 AND p.permission_name IN ('SELECT', 'INSERT', 'UPDATE', 'DELETE', 'CONTROL'))
+			ORDER BY pr.name, p.permission_name`, schema, cleanTable), dbName, dbType)
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var privs []TablePrivilege
+	for rows.Next() {
+		var p TablePrivilege
+		rows.Scan(&p.Grantee, &p.Privilege, &p.Scope, &p.Grantable, &p.Denied)
+		privs = append(privs, p)
+	}
+	return privs, rows.Err()
+}
+
+// GetTableAccess reports which DML privileges the connected user holds on a
+// table. MySQL privileges are combined from global, schema and table grants
+// of the current account; privileges held only through roles are not seen.
+func GetTableAccess(db *sql.DB, dbName, tableName, dbType string) (*TableAccess, error) {
+	access := &TableAccess{}
+	switch dbType {
+	case "postgres":
+		rel := pgRegclass(tableName)
+		query := fmt.Sprintf(`SELECT current_user,
+			has_table_privilege('%[1]s', 'SELECT'), has_table_privilege('%[1]s', 'INSERT'),
+			has_table_privilege('%[1]s', 'UPDATE'), has_table_privilege('%[1]s', 'DELETE')`, rel)
+		err := db.QueryRow(query).Scan(&access.User, &access.Select, &access.Insert, &access.Update, &access.Delete)
+		return access, err
+	case "sqlserver":
+		name := strings.ReplaceAll(FormatTableName(tableName, dbType), "'", "''")
+		query := PrependUseDatabase(fmt.Sprintf(`SELECT USER_NAME(),
+			COALESCE(HAS_PERMS_BY_NAME('%[1]s', 'OBJECT', 'SELECT'), 0), COALESCE(HAS_PERMS_BY_NAME('%[1]s', 'OBJECT', 'INSERT'), 0),
+			COALESCE(HAS_PERMS_BY_NAME('%[1]s', 'OBJECT', 'UPDATE'), 0), COALESCE(HAS_PERMS_BY_NAME('%[1]s', 'OBJECT', 'DELETE'), 0)`, name),
+			dbName, dbType)
+		err := db.QueryRow(query).Scan(&access.User, &access.Select, &access.Insert, &access.Update, &access.Delete)
+		return access, err
+	case "mysql":
+		if err := db.QueryRow("SELECT CURRENT_USER()").Scan(&access.User); err != nil {
+			return nil, err
+		}
+		user, host, _ := strings.Cut(access.User, "@")
+		grantee := strings.ReplaceAll(fmt.Sprintf("'%s'@'%s'", user, host), "'", "''")
+		query := fmt.Sprintf(`
+			SELECT PRIVILEGE_TYPE FROM information_schema.USER_PRIVILEGES WHERE GRANTEE = '%[1]s'
+			UNION SELECT PRIVILEGE_TYPE FROM information_schema.SCHEMA_PRIVILEGES
+				WHERE GRANTEE = '%[1]s' AND TABLE_SCHEMA = '%[2]s'
+			UNION SELECT PRIVILEGE_TYPE FROM information_schema.TABLE_PRIVILEGES
+				WHERE GRANTEE = '%[1]s' AND TABLE_SCHEMA = '%[2]s' AND TABLE_NAME = '%[3]s'`,
+			grantee, dbName, tableName)
+		rows, err := db.Query(query)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		var privs []string
+		for rows.Next() {
+			var p string
+			rows.Scan(&p)
+			privs = append(privs, p)
+		}
+		access.Select = slices.Contains(privs, "SELECT")
+		access.Insert = slices.Contains(privs, "INSERT")
+		access.Update = slices.Contains(privs, "UPDATE")
+		access.Delete = slices.Contains(privs, "DELETE")
+		return access, rows.Err()
+	}
+	return nil, fmt.Errorf("unsupported database type: %s", dbType)
+}
@@ -0,0 +1,300 @@
+// objects.go lists the non-table objects in a database (views, materialized
+// views, functions, procedures, triggers and sequences), fetches their
+// definition source, and builds parameterized calls for stored procedures.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Object kinds, in the order they are grouped in the table list
+const (
+	KindTable            = "table"
+	KindView             = "view"
+	KindMaterializedView = "materialized view"
+	KindFunction         = "function"
+	KindProcedure        = "procedure"
+	KindTrigger          = "trigger"
+	KindSequence         = "sequence"
+)
+
+// ObjectKinds lists every kind in display order
+var ObjectKinds = []string{KindTable, KindView, KindMaterializedView, KindFunction, KindProcedure, KindTrigger, KindSequence}
+
+// DBObject is a named object in a database
+type DBObject struct {
+	Name string
+	Kind string
+}
+
+// IsSelectable reports whether rows can be selected from the object
+func (o DBObject) IsSelectable() bool {
+	return o.Kind == KindTable || o.Kind == KindView || o.Kind == KindMaterializedView
+}
+
+// ListObjects returns the views, materialized views, functions, procedures,
+// triggers and sequences in a database, sorted by kind and then name
+func ListObjects(db *sql.DB, dbName, dbType string) ([]DBObject, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT TABLE_NAME, 'view' FROM INFORMATION_SCHEMA.VIEWS WHERE TABLE_SCHEMA = '%s'
+			UNION ALL
+			SELECT ROUTINE_NAME, LOWER(ROUTINE_TYPE) FROM INFORMATION_SCHEMA.ROUTINES WHERE ROUTINE_SCHEMA = '%s'
+			UNION ALL
+			SELECT TRIGGER_NAME, 'trigger' FROM INFORMATION_SCHEMA.TRIGGERS WHERE TRIGGER_SCHEMA = '%s'`,
+			dbName, dbName, dbName)
+	case "postgres":
+		query = fmt.Sprintf(`
+			SELECT schemaname || '.' || viewname, 'view' FROM pg_views WHERE %s
+			UNION ALL
+			SELECT schemaname || '.' || matviewname, 'materialized view' FROM pg_matviews WHERE %s
+			UNION ALL
+			SELECT DISTINCT n.nspname || '.' || p.proname, CASE p.prokind WHEN 'p' THEN 'procedure' ELSE 'function' END
+			FROM pg_proc p
+			JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE %s AND p.prokind IN ('f', 'p')
+			UNION ALL
+			SELECT n.nspname || '.' || t.tgname, 'trigger'
+			FROM pg_trigger t
+			JOIN pg_class c ON c.oid = t.tgrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE %s AND NOT t.tgisinternal
+			UNION ALL
+			SELECT schemaname || '.' || sequencename, 'sequence' FROM pg_sequences WHERE %s`,
+			pgUserSchemas("schemaname"), pgUserSchemas("schemaname"), pgUserSchemas("n.nspname"),
+			pgUserSchemas("n.nspname"), pgUserSchemas("schemaname"))
+	case "sqlserver":
+		query = fmt.Sprintf(`
+			SELECT '[' + s.name + '].[' + o.name + ']',
+				CASE o.type
+					WHEN 'V' THEN 'view'
+					WHEN 'P' THEN 'procedure'
+					WHEN 'TR' THEN 'trigger'
+					WHEN 'SO' THEN 'sequence'
+					ELSE 'function'
+				END
+			FROM [%s].sys.objects o
+			JOIN [%s].sys.schemas s ON s.schema_id = o.schema_id
+			WHERE o.type IN ('V', 'P', 'FN', 'IF', 'TF', 'TR', 'SO') AND o.is_ms_shipped = 0`, dbName, dbName)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []DBObject
+	for rows.Next() {
+		var obj DBObject
+		rows.Scan(&obj.Name, &obj.Kind)
+		objects = append(objects, obj)
+	}
+	SortObjects(objects)
+	return objects, nil
+}
+
+// SortObjects orders objects by kind (as in ObjectKinds) and then name
+func SortObjects(objects []DBObject) {
+	slices.SortStableFunc(objects, func(a, b DBObject) int {
+		if d := slices.Index(ObjectKinds, a.Kind) - slices.Index(ObjectKinds, b.Kind); d != 0 {
+			return d
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// GetObjectDefinition returns the source of a view, routine, trigger or
+// sequence. Tables are handled by GetTableDDL.
+func GetObjectDefinition(db *sql.DB, dbName string, obj DBObject, dbType string) (string, error) {
+	switch dbType {
+	case "mysql":
+		return mysqlObjectDefinition(db, dbName, obj)
+	case "postgres":
+		return postgresObjectDefinition(db, obj)
+	case "sqlserver":
+		return sqlserverObjectDefinition(db, dbName, obj)
+	}
+	return "", fmt.Errorf("unsupported database type: %s", dbType)
+}
+
+// mysqlObjectDefinition uses SHOW CREATE, whose result column holding the
+// statement differs per object kind
+func mysqlObjectDefinition(db *sql.DB, dbName string, obj DBObject) (string, error) {
+	column := "Create " + strings.ToUpper(obj.Kind[:1]) + obj.Kind[1:]
+	if obj.Kind == KindTrigger {
+		column = "SQL Original Statement"
+	}
+	query := fmt.Sprintf("SHOW CREATE %s %s", strings.ToUpper(obj.Kind), QualifiedTableName(dbName, obj.Name, "mysql"))
+	result, err := RunQuery(db, query)
+	if err != nil {
+		return "", err
+	}
+	col := slices.Index(result.Columns, column)
+	if col == -1 || len(result.Rows) == 0 {
+		return "", fmt.Errorf("no definition found for %s %s", obj.Kind, obj.Name)
+	}
+	return result.Rows[0][col], nil
+}
+
+func postgresObjectDefinition(db *sql.DB, obj DBObject) (string, error) {
+	schema, name := SplitSchemaTable(obj.Name, "postgres")
+	var query string
+	switch obj.Kind {
+	case KindView, KindMaterializedView:
+		query = fmt.Sprintf("SELECT 'CREATE %s %s AS' || chr(10) || pg_get_viewdef('%s'::regclass, true)",
+			strings.ToUpper(obj.Kind), obj.Name, pgRegclass(obj.Name))
+	case KindFunction, KindProcedure:
+		query = fmt.Sprintf(`
+			SELECT pg_get_functiondef(p.oid)
+			FROM pg_proc p
+			JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = '%s' AND p.proname = '%s'`, schema, name)
+	case KindTrigger:
+		query = fmt.Sprintf(`
+			SELECT pg_get_triggerdef(t.oid, true) || ';'
+			FROM pg_trigger t
+			JOIN pg_class c ON c.oid = t.tgrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = '%s' AND t.tgname = '%s'`, schema, name)
+	case KindSequence:
+		query = fmt.Sprintf(`
+			SELECT format('CREATE SEQUENCE %%s.%%s AS %%s START %%s INCREMENT %%s MINVALUE %%s MAXVALUE %%s%%s;',
+				schemaname, sequencename, data_type, start_value, increment_by, min_value, max_value,
+				CASE WHEN cycle THEN ' CYCLE' ELSE '' END)
+			FROM pg_sequences
+			WHERE schemaname = '%s' AND sequencename = '%s'`, schema, name)
+	}
+	return joinDefinitions(db, query, obj)
+}
+
+func sqlserverObjectDefinition(db *sql.DB, dbName string, obj DBObject) (string, error) {
+	objectID := fmt.Sprintf("OBJECT_ID('%s')", QualifiedTableName(dbName, obj.Name, "sqlserver"))
+	query := fmt.Sprintf("SELECT OBJECT_DEFINITION(%s)", objectID)
+	if obj.Kind == KindSequence {
+		query = fmt.Sprintf(`
+			SELECT 'CREATE SEQUENCE %s AS ' + TYPE_NAME(user_type_id)
+				+ ' START WITH ' + CAST(start_value AS VARCHAR(40))
+				+ ' INCREMENT BY ' + CAST(increment AS VARCHAR(40)) + ';'
+			FROM [%s].sys.sequences
+			WHERE object_id = %s`, FormatTableName(obj.Name, "sqlserver"), dbName, objectID)
+	}
+	return joinDefinitions(db, query, obj)
+}
+
+// joinDefinitions runs a query returning one definition per row (overloaded
+// functions have several) and joins them
+func joinDefinitions(db *sql.DB, query string, obj DBObject) (string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var defs []string
+	for rows.Next() {
+		var def sql.NullString
+		rows.Scan(&def)
+		if def.Valid {
+			defs = append(defs, strings.TrimSpace(def.String))
+		}
+	}
+	if len(defs) == 0 {
+		return "", fmt.Errorf("no definition found for %s %s", obj.Kind, obj.Name)
+	}
+	return strings.Join(defs, "\n\n"), nil
+}
+
+// RoutineParam is a parameter of a stored procedure
+type RoutineParam struct {
+	Name     string
+	DataType string
+	Mode     string // IN, OUT or INOUT
+}
+
+// IsInput reports whether the caller supplies a value for the parameter
+func (p RoutineParam) IsInput() bool {
+	return p.Mode != "OUT"
+}
+
+// GetRoutineParams returns a procedure's parameters in declaration order
+func GetRoutineParams(db *sql.DB, dbName, name, dbType string) ([]RoutineParam, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT PARAMETER_NAME, DATA_TYPE, COALESCE(PARAMETER_MODE, 'IN')
+			FROM INFORMATION_SCHEMA.PARAMETERS
+			WHERE SPECIFIC_SCHEMA = '%s' AND SPECIFIC_NAME = '%s' AND ORDINAL_POSITION > 0
+			ORDER BY ORDINAL_POSITION`, dbName, name)
+	case "postgres":
+		schema, routine := SplitSchemaTable(name, dbType)
+		query = fmt.Sprintf(`
+			SELECT COALESCE(p.parameter_name, '$' || p.ordinal_position), p.data_type, p.parameter_mode
+			FROM information_schema.parameters p
+			WHERE p.specific_schema = '%s' AND p.specific_name = (
+				SELECT MIN(specific_name) FROM information_schema.routines
+				WHERE routine_schema = '%s' AND routine_name = '%s'
+			)
+			ORDER BY p.ordinal_position`, schema, schema, routine)
+	case "sqlserver":
+		query = fmt.Sprintf(`
+			SELECT name, TYPE_NAME(user_type_id), CASE WHEN is_output = 1 THEN 'INOUT' ELSE 'IN' END
+			FROM [%s].sys.parameters
+			WHERE object_id = OBJECT_ID('%s') AND parameter_id > 0
+			ORDER BY parameter_id`, dbName, QualifiedTableName(dbName, name, dbType))
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var params []RoutineParam
+	for rows.Next() {
+		var p RoutineParam
+		rows.Scan(&p.Name, &p.DataType, &p.Mode)
+		params = append(params, p)
+	}
+	return params, nil
+}
+
+// BuildProcedureCall returns a statement calling a procedure with a
+// placeholder for each input parameter. MySQL OUT parameters are bound to
+// session variables; other engines pass NULL for them.
+func BuildProcedureCall(name string, params []RoutineParam, dbType string) string {
+	var parts []string
+	n := 0
+	for _, p := range params {
+		value := "NULL"
+		if p.IsInput() {
+			n++
+			value = Placeholder(n, dbType)
+		} else if dbType == "mysql" {
+			value = "@" + p.Name
+		}
+		if dbType == "sqlserver" {
+			value = p.Name + " = " + value
+		}
+		parts = append(parts, value)
+	}
+
+	switch dbType {
+	case "sqlserver":
+		call := "EXEC " + FormatTableName(name, dbType)
+		if len(parts) > 0 {
+			call += " " + strings.Join(parts, ", ")
+		}
+		return call
+	case "mysql":
+		return fmt.Sprintf("CALL %s(%s)", QuoteIdent(name, dbType), strings.Join(parts, ", "))
+	default:
+		return fmt.Sprintf("CALL %s(%s)", name, strings.Join(parts, ", "))
+	}
+}
@@ -0,0 +1,279 @@
+// schema_diff.go compares the schemas of two databases, possibly on different
+// connections of the same engine. It reports missing and extra tables, column
+// type, nullability and default differences, and PK and FK differences, and
+// can render the result as a text report or a best-effort migration script.
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TableSchema is the catalog metadata of a single table
+type TableSchema struct {
+	Name    string
+	Columns []ColumnInfo
+	PK      []string
+	FKs     []FKReference
+	Indexes []IndexInfo
+}
+
+// ColumnChange describes a column present on both sides with differences
+type ColumnChange struct {
+	Source      ColumnInfo
+	Target      ColumnInfo
+	Differences []string
+}
+
+// TableSchemaDiff lists the differences within a table present on both sides
+type TableSchemaDiff struct {
+	Table          string
+	MissingColumns []ColumnInfo // In source only
+	ExtraColumns   []ColumnInfo // In target only
+	ChangedColumns []ColumnChange
+	SourcePK       []string // Set when the PKs differ
+	TargetPK       []string
+	MissingFKs     []FKReference // All columns of each constraint
+	ExtraFKs       []FKReference
+}
+
+// SchemaDiff is the outcome of DiffSchemas. "Missing" means present in the
+// source but not the target; "extra" means present in the target only.
+type SchemaDiff struct {
+	Source        map[string]*TableSchema
+	Target        map[string]*TableSchema
+	MissingTables []string
+	ExtraTables   []string
+	Tables        []TableSchemaDiff
+}
+
+// LoadSchema reads the metadata of every table in the side's database
+func LoadSchema(side TableSide) (map[string]*TableSchema, error) {
+	tables, err := ListTables(side.DB, side.DBName, side.DBType)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(map[string]*TableSchema, len(tables))
+	for _, table := range tables {
+		ts, err := LoadTableSchema(side, table)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		schema[table] = ts
+	}
+	return schema, nil
+}
+
+// LoadTableSchema reads the metadata of a single table
+func LoadTableSchema(side TableSide, table string) (*TableSchema, error) {
+	cols, err := GetColumnInfo(side.DB, side.DBName, table, side.DBType)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := GetPrimaryKey(side.DB, side.DBName, table, side.DBType)
+	if err != nil {
+		return nil, err
+	}
+	fks, err := GetOutgoingFKs(side.DB, side.DBName, table, side.DBType)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := GetIndexes(side.DB, side.DBName, table, side.DBType)
+	if err != nil {
+		return nil, err
+	}
+	return &TableSchema{Name: table, Columns: cols, PK: pk, FKs: fks, Indexes: indexes}, nil
+}
+
+// DiffSchemas compares target against source
+func DiffSchemas(source, target map[string]*TableSchema) *SchemaDiff {
+	d := &SchemaDiff{Source: source, Target: target}
+	for _, name := range sortedTableNames(source) {
+		dst, ok := target[name]
+		if !ok {
+			d.MissingTables = append(d.MissingTables, name)
+			continue
+		}
+		if td := diffTableSchema(source[name], dst); td != nil {
+			d.Tables = append(d.Tables, *td)
+		}
+	}
+	for _, name := range sortedTableNames(target) {
+		if _, ok := source[name]; !ok {
+			d.ExtraTables = append(d.ExtraTables, name)
+		}
+	}
+	return d
+}
+
+func sortedTableNames(schema map[string]*TableSchema) []string {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func diffTableSchema(src, dst *TableSchema) *TableSchemaDiff {
+	td := &TableSchemaDiff{Table: src.Name}
+
+	dstCols := make(map[string]ColumnInfo, len(dst.Columns))
+	for _, col := range dst.Columns {
+		dstCols[col.Name] = col
+	}
+	srcCols := make(map[string]bool, len(src.Columns))
+	for _, col := range src.Columns {
+		srcCols[col.Name] = true
+		other, ok := dstCols[col.Name]
+		if !ok {
+			td.MissingColumns = append(td.MissingColumns, col)
+			continue
+		}
+		var diffs []string
+		if ColumnTypeSQL(col) != ColumnTypeSQL(other) {
+			diffs = append(diffs, fmt.Sprintf("type %s vs %s", ColumnTypeSQL(col), ColumnTypeSQL(other)))
+		}
+		if col.IsNullable != other.IsNullable {
+			diffs = append(diffs, fmt.Sprintf("nullable %t vs %t", col.IsNullable, other.IsNullable))
+		}
+		if col.Default != other.Default {
+			diffs = append(diffs, fmt.Sprintf("default %q vs %q", col.Default, other.Default))
+		}
+		if len(diffs) > 0 {
+			td.ChangedColumns = append(td.ChangedColumns, ColumnChange{Source: col, Target: other, Differences: diffs})
+		}
+	}
+	for _, col := range dst.Columns {
+		if !srcCols[col.Name] {
+			td.ExtraColumns = append(td.ExtraColumns, col)
+		}
+	}
+
+	if !slices.Equal(src.PK, dst.PK) {
+		td.SourcePK, td.TargetPK = src.PK, dst.PK
+	}
+
+	// Compare whole constraints so a composite FK is added or dropped as one
+	fkKey := func(fk foreignKey) string {
+		return strings.Join(fk.columns, ",") + "->" + CleanTableName(fk.referencedTable, "sqlserver") +
+			"." + strings.Join(fk.referencedColumns, ",")
+	}
+	unmatched := func(fks, other []FKReference) []FKReference {
+		keys := make(map[string]bool)
+		for _, fk := range groupForeignKeys(other) {
+			keys[fkKey(fk)] = true
+		}
+		names := make(map[string]bool)
+		for _, fk := range groupForeignKeys(fks) {
+			if !keys[fkKey(fk)] {
+				names[fk.name] = true
+			}
+		}
+		var refs []FKReference
+		for _, ref := range fks {
+			if names[ref.ConstraintName] {
+				refs = append(refs, ref)
+			}
+		}
+		return refs
+	}
+	td.MissingFKs = unmatched(src.FKs, dst.FKs)
+	td.ExtraFKs = unmatched(dst.FKs, src.FKs)
+
+	if len(td.MissingColumns)+len(td.ExtraColumns)+len(td.ChangedColumns)+
+		len(td.MissingFKs)+len(td.ExtraFKs) == 0 && td.SourcePK == nil && td.TargetPK == nil {
+		return nil
+	}
+	return td
+}
+
+// IsEmpty reports whether the schemas match
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.MissingTables)+len(d.ExtraTables)+len(d.Tables) == 0
+}
+
+// Report renders the diff as plain text. Lines start with + (missing from
+// target), - (extra in target) or ~ (different).
+func (d *SchemaDiff) Report() string {
+	var b strings.Builder
+	for _, t := range d.MissingTables {
+		fmt.Fprintf(&b, "+ table %s (missing from target)\n", t)
+	}
+	for _, t := range d.ExtraTables {
+		fmt.Fprintf(&b, "- table %s (only in target)\n", t)
+	}
+	for _, td := range d.Tables {
+		fmt.Fprintf(&b, "~ table %s\n", td.Table)
+		for _, col := range td.MissingColumns {
+			fmt.Fprintf(&b, "    + column %s %s\n", col.Name, ColumnTypeSQL(col))
+		}
+		for _, col := range td.ExtraColumns {
+			fmt.Fprintf(&b, "    - column %s %s\n", col.Name, ColumnTypeSQL(col))
+		}
+		for _, ch := range td.ChangedColumns {
+			fmt.Fprintf(&b, "    ~ column %s: %s\n", ch.Source.Name, strings.Join(ch.Differences, ", "))
+		}
+		if td.SourcePK != nil || td.TargetPK != nil {
+			fmt.Fprintf(&b, "    ~ primary key (%s) vs (%s)\n", strings.Join(td.SourcePK, ", "), strings.Join(td.TargetPK, ", "))
+		}
+		for _, fk := range groupForeignKeys(td.MissingFKs) {
+			fmt.Fprintf(&b, "    + foreign key %s\n", fk)
+		}
+		for _, fk := range groupForeignKeys(td.ExtraFKs) {
+			fmt.Fprintf(&b, "    - foreign key %s\n", fk)
+		}
+	}
+	if d.IsEmpty() {
+		b.WriteString("Schemas match\n")
+	}
+	return b.String()
+}
+
+// MigrationScript renders best-effort DDL that would make the target match
+// the source. Destructive statements (drops) are emitted commented out.
+func (d *SchemaDiff) MigrationScript(dbType string) string {
+	var b strings.Builder
+	b.WriteString("-- Schema migration generated by dbsurf. Review before running.\n\n")
+
+	for _, t := range d.MissingTables {
+		b.WriteString(BuildCreateTable(d.Source[t], dbType))
+		b.WriteString("\n\n")
+	}
+	for _, t := range d.ExtraTables {
+		fmt.Fprintf(&b, "-- DROP TABLE %s;\n", FormatTableName(t, dbType))
+	}
+	for _, td := range d.Tables {
+		table := FormatTableName(td.Table, dbType)
+		fmt.Fprintf(&b, "-- %s\n", td.Table)
+		for _, col := range td.MissingColumns {
+			fmt.Fprintf(&b, "ALTER TABLE %s ADD %s;\n", table, ColumnDefinitionSQL(col, dbType))
+		}
+		for _, col := range td.ExtraColumns {
+			fmt.Fprintf(&b, "-- ALTER TABLE %s DROP COLUMN %s;\n", table, QuoteIdent(col.Name, dbType))
+		}
+		for _, ch := range td.ChangedColumns {
+			for _, stmt := range alterColumnSQL(table, ch.Source, dbType) {
+				b.WriteString(stmt + "\n")
+			}
+		}
+		if td.SourcePK != nil || td.TargetPK != nil {
+			fmt.Fprintf(&b, "-- primary key differs: (%s) vs (%s), adjust manually\n",
+				strings.Join(td.SourcePK, ", "), strings.Join(td.TargetPK, ", "))
+		}
+		for _, fk := range groupForeignKeys(td.MissingFKs) {
+			fmt.Fprintf(&b, "ALTER TABLE %s ADD %s;\n", table, fk.sql(dbType))
+		}
+		for _, fk := range groupForeignKeys(td.ExtraFKs) {
+			drop := "DROP CONSTRAINT"
+			if dbType == "mysql" {
+				drop = "DROP FOREIGN KEY"
+			}
+			fmt.Fprintf(&b, "-- ALTER TABLE %s %s %s;\n", table, drop, QuoteIdent(fk.name, dbType))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+// statement.go classifies SQL text as reading or writing so read-only
+// connections can refuse writes before they reach the server. Anything not
+// recognised as a read is treated as a write.
+package db
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// readKeywords start statements that do not change data. SET is left out
+// since session settings include the read-only guard itself, e.g.
+// SET default_transaction_read_only = off.
+var readKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "DESCRIBE": true, "DESC": true,
+	"EXPLAIN": true, "VALUES": true, "TABLE": true, "USE": true,
+}
+
+// writeKeywords make an otherwise reading statement write, e.g. a data
+// modifying CTE, SELECT ... INTO or EXPLAIN ANALYZE DELETE
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true, "INTO": true,
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "GRANT": true, "REVOKE": true,
+}
+
+// IsWriteStatement reports whether query contains any statement that may
+// change data or schema. Strings, quoted identifiers and comments are
+// ignored. SELECT ... FOR UPDATE counts as a write since it takes row locks,
+// and SET since it can turn off read-only mode for the session.
+func IsWriteStatement(query string) bool {
+	for _, stmt := range statementWords(query) {
+		if len(stmt) == 0 {
+			continue
+		}
+		if !readKeywords[stmt[0]] {
+			return true
+		}
+		for _, w := range stmt[1:] {
+			if writeKeywords[w] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReturnsRows reports whether the last statement in query produces a result
+// set: reads, procedure calls, and writes with RETURNING or OUTPUT clauses.
+// Other writes are run with Exec so their affected row count is known.
+func ReturnsRows(query string) bool {
+	stmts := statementWords(query)
+	for i := len(stmts) - 1; i >= 0; i-- {
+		stmt := stmts[i]
+		if len(stmt) == 0 {
+			continue
+		}
+		switch stmt[0] {
+		case "CALL", "EXEC", "EXECUTE":
+			return true
+		}
+		if readKeywords[stmt[0]] && stmt[0] != "USE" {
+			return true
+		}
+		return slices.Contains(stmt, "RETURNING") || slices.Contains(stmt, "OUTPUT")
+	}
+	return false
+}
+
+// statementWords splits query into statements on ';' and returns the
+// upper-cased keywords and identifiers of each, skipping literals and comments
+func statementWords(query string) [][]string {
+	var stmts [][]string
+	var words []string
+	rs := []rune(query)
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case r == ';':
+			stmts = append(stmts, words)
+			words = nil
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/') {
+				i++
+			}
+			i++
+		case r == '\'' || r == '"' || r == '`' || r == '[':
+			end := r
+			if r == '[' {
+				end = ']'
+			}
+			for i++; i < len(rs); i++ {
+				if rs[i] == end {
+					// Doubled quotes escape themselves
+					if i+1 < len(rs) && rs[i+1] == end && end != ']' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i+1 < len(rs) && (unicode.IsLetter(rs[i+1]) || unicode.IsDigit(rs[i+1]) || rs[i+1] == '_') {
+				i++
+			}
+			words = append(words, strings.ToUpper(string(rs[start:i+1])))
+		}
+	}
+	return append(stmts, words)
+}
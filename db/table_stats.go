@@ -0,0 +1,90 @@
+// table_stats.go reads approximate table sizes and activity from the catalog:
+// row estimates, total/data/index size and, where the engine tracks them, the
+// last analyze and last update times.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TableStats holds catalog statistics for one table. Counts are estimates;
+// times are empty when the engine doesn't track them.
+type TableStats struct {
+	Rows         int64
+	TotalBytes   int64
+	DataBytes    int64
+	IndexBytes   int64
+	LastAnalyzed string
+	LastUpdated  string
+}
+
+// GetTableStats returns statistics for every table in the database, keyed by
+// table name in the same form ListTables returns
+func GetTableStats(db *sql.DB, dbName, dbType string) (map[string]TableStats, error) {
+	var query string
+	switch dbType {
+	case "mysql":
+		query = fmt.Sprintf(`
+			SELECT
+				TABLE_NAME,
+				COALESCE(TABLE_ROWS, 0),
+				COALESCE(DATA_LENGTH, 0) + COALESCE(INDEX_LENGTH, 0),
+				COALESCE(DATA_LENGTH, 0),
+				COALESCE(INDEX_LENGTH, 0),
+				'',
+				COALESCE(DATE_FORMAT(UPDATE_TIME, '%%Y-%%m-%%d %%H:%%i'), '')
+			FROM INFORMATION_SCHEMA.TABLES
+			WHERE TABLE_SCHEMA = '%s' AND TABLE_TYPE = 'BASE TABLE'`, dbName)
+	case "postgres":
+		query = `
+			SELECT
+				n.nspname || '.' || c.relname,
+				GREATEST(c.reltuples, 0)::bigint,
+				pg_total_relation_size(c.oid),
+				pg_relation_size(c.oid),
+				pg_indexes_size(c.oid),
+				COALESCE(to_char(GREATEST(s.last_analyze, s.last_autoanalyze), 'YYYY-MM-DD HH24:MI'), ''),
+				''
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+			WHERE c.relkind IN ('r', 'p') AND ` + pgUserSchemas("n.nspname")
+	case "sqlserver":
+		query = PrependUseDatabase(`
+			SELECT
+				'[' + s.name + '].[' + t.name + ']',
+				SUM(CASE WHEN ps.index_id IN (0, 1) THEN ps.row_count ELSE 0 END),
+				SUM(ps.reserved_page_count) * 8192,
+				SUM(CASE WHEN ps.index_id IN (0, 1) THEN ps.used_page_count ELSE 0 END) * 8192,
+				SUM(CASE WHEN ps.index_id > 1 THEN ps.used_page_count ELSE 0 END) * 8192,
+				COALESCE((
+					SELECT CONVERT(VARCHAR(16), MAX(STATS_DATE(st.object_id, st.stats_id)), 120)
+					FROM sys.stats st WHERE st.object_id = t.object_id
+				), ''),
+				COALESCE((
+					SELECT CONVERT(VARCHAR(16), MAX(us.last_user_update), 120)
+					FROM sys.dm_db_index_usage_stats us
+					WHERE us.database_id = DB_ID() AND us.object_id = t.object_id
+				), '')
+			FROM sys.tables t
+			JOIN sys.schemas s ON s.schema_id = t.schema_id
+			JOIN sys.dm_db_partition_stats ps ON ps.object_id = t.object_id
+			GROUP BY s.name, t.name, t.object_id`, dbName, dbType)
+	}
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]TableStats)
+	for rows.Next() {
+		var name string
+		var s TableStats
+		rows.Scan(&name, &s.Rows, &s.TotalBytes, &s.DataBytes, &s.IndexBytes, &s.LastAnalyzed, &s.LastUpdated)
+		stats[name] = s
+	}
+	return stats, nil
+}
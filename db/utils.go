@@ -1,8 +1,9 @@
-// utils.go provides SQL Server-specific utilities for handling schema-qualified
-// table names and query formatting.
+// utils.go provides dialect-specific utilities for handling schema-qualified
+// table names, identifier quoting, bind parameters and query formatting.
 package db
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -34,6 +35,31 @@ func ExtractSchema(tableName, dbType string) string {
 	return "dbo" // Default schema for SQL Server
 }
 
+// SplitSchemaTable splits a possibly schema-qualified table name into its
+// schema and table. Postgres defaults to "public" and SQL Server to "dbo";
+// MySQL has no schemas so the schema is always empty.
+// For example: "sales.orders" -> ("sales", "orders"), "users" -> ("public", "users")
+func SplitSchemaTable(tableName, dbType string) (schema, table string) {
+	switch dbType {
+	case "sqlserver":
+		return ExtractSchema(tableName, dbType), CleanTableName(tableName, dbType)
+	case "postgres":
+		if idx := strings.LastIndex(tableName, "."); idx != -1 {
+			return strings.Trim(tableName[:idx], `"`), strings.Trim(tableName[idx+1:], `"`)
+		}
+		return "public", strings.Trim(tableName, `"`)
+	}
+	return "", tableName
+}
+
+// pgRegclass renders a table name as a quoted, schema-qualified name suitable
+// for a '...'::regclass cast
+func pgRegclass(tableName string) string {
+	schema, table := SplitSchemaTable(tableName, "postgres")
+	name := QuoteIdent(schema, "postgres") + "." + QuoteIdent(table, "postgres")
+	return strings.ReplaceAll(name, "'", "''")
+}
+
 // PrependUseDatabase prepends USE [database]; to a query for SQL Server.
 // For other database types, returns the query unchanged.
 func PrependUseDatabase(query, database, dbType string) string {
@@ -59,3 +85,54 @@ func FormatTableName(tableName, dbType string) string {
 	// No schema, just wrap table name
 	return "[" + strings.Trim(tableName, "[]") + "]"
 }
+
+// QuoteIdent quotes an identifier for the given database type.
+// For example: mysql `name`, postgres "name", sqlserver [name]
+func QuoteIdent(name, dbType string) string {
+	switch dbType {
+	case "postgres":
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	case "sqlserver":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+}
+
+// Placeholder returns the bind parameter marker for the nth (1-based) argument.
+// For example: mysql ?, postgres $1, sqlserver @p1
+func Placeholder(n int, dbType string) string {
+	switch dbType {
+	case "postgres":
+		return fmt.Sprintf("$%d", n)
+	case "sqlserver":
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return "?"
+	}
+}
+
+// QualifiedTableName prefixes a table name with its database so it can be
+// queried regardless of the connection's current database.
+// For example: mysql `shop`.`users`, sqlserver [shop].[dbo].[users]
+func QualifiedTableName(dbName, tableName, dbType string) string {
+	switch dbType {
+	case "sqlserver":
+		return fmt.Sprintf("[%s].[%s].[%s]", dbName, ExtractSchema(tableName, dbType), CleanTableName(tableName, dbType))
+	case "mysql":
+		if dbName == "" {
+			return QuoteIdent(tableName, dbType)
+		}
+		return QuoteIdent(dbName, dbType) + "." + QuoteIdent(tableName, dbType)
+	default:
+		return tableName
+	}
+}
+
+// SQLLiteral renders a result value as a SQL literal, mapping "NULL" to NULL
+func SQLLiteral(value string) string {
+	if value == "NULL" {
+		return "NULL"
+	}
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
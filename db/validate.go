@@ -1,6 +1,6 @@
 // validate.go provides connection string validation for different database types.
 // It validates URL format, required fields, and port ranges before attempting
-// to connect.
+// to connect, after expanding ${ENV_VAR} and ${file:/path} references.
 package db
 
 import (
@@ -25,16 +25,30 @@ func ValidateConnectionString(connString string) error {
 		return &ValidationError{Field: "connection", Message: "connection string cannot be empty"}
 	}
 
+	// Validate what will actually be connected to, without echoing any
+	// expanded values back
+	connString, values, err := expandConnString(connString)
+	if err != nil {
+		return &ValidationError{Field: "expansion", Message: err.Error()}
+	}
+	if strings.TrimSpace(connString) == "" {
+		return &ValidationError{Field: "connection", Message: "connection string is empty after expansion"}
+	}
+
 	dbType := DetectDBType(connString)
 
 	switch dbType {
 	case "postgres":
-		return validatePostgres(connString)
+		err = validatePostgres(connString)
 	case "sqlserver":
-		return validateSQLServer(connString)
+		err = validateSQLServer(connString)
 	default:
-		return validateMySQL(connString)
+		err = validateMySQL(connString)
+	}
+	if verr, ok := err.(*ValidationError); ok {
+		verr.Message = redact(verr.Message, values)
 	}
+	return err
 }
 
 func validatePostgres(connString string) error {